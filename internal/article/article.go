@@ -0,0 +1,340 @@
+package article
+
+import (
+	"io"
+	"fmt"
+	"math"
+	"bytes"
+	"regexp"
+	"context"
+	"strings"
+	"gator/internal/fetch"
+	"gator/internal/sanitize"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// Full text extraction in the spirit of Arc90's Readability: paragraphs score
+// the elements holding them by their length and commas, class names and ids
+// hint at content or clutter, and the best scored element is kept together
+// with the siblings that look like part of the same article.
+
+// minArticleLength is how much text an extraction needs to be trusted.
+const minArticleLength = 250
+
+var (
+	unlikely = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|newsletter|popup|promo|ad-break|agegate|pagination|pager|tweet`)
+	maybe    = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positive = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negative = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// removed elements never hold article text.
+var removed = map[atom.Atom]bool{
+	atom.Aside:    true,
+	atom.Button:   true,
+	atom.Footer:   true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Input:    true,
+	atom.Link:     true,
+	atom.Meta:     true,
+	atom.Nav:      true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+}
+
+// Fetch downloads the page at page_url and returns its article as sanitized
+// HTML.
+func Fetch(ctx context.Context, fetcher *fetch.Fetcher, page_url string) (string, error) {
+	resp, err := fetcher.Get(ctx, page_url, "text/html, application/xhtml+xml")
+	if err != nil {
+		return "", err
+	}
+
+	if c_type := resp.Header.Get("Content-Type"); c_type != "" && !strings.Contains(c_type, "html") {
+		return "", fmt.Errorf("Not an HTML page - %s", c_type)
+	}
+
+	// html.Parse reads UTF-8, pages in other encodings are decoded by their
+	// BOM, the charset of the Content-Type or their <meta charset>.
+	page, err := charset.NewReader(bytes.NewReader(resp.Body), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+
+	// Redirects are followed, links resolve against the final page.
+	return Extract(page, resp.URL.String())
+}
+
+// Extract finds the article in an HTML page and returns it as sanitized HTML,
+// links resolved against base.
+func Extract(page io.Reader, base string) (string, error) {
+	doc, err := html.Parse(page)
+	if err != nil {
+		return "", err
+	}
+
+	body := find(doc, atom.Body)
+	if body == nil {
+		return "", fmt.Errorf("Page has no body")
+	}
+
+	clean(body)
+
+	top := topCandidate(body)
+	if top == nil {
+		return "", fmt.Errorf("Couldn't find the article on the page")
+	}
+
+	var b strings.Builder
+	for _, v := range withSiblings(top) {
+		if err := html.Render(&b, v); err != nil {
+			return "", err
+		}
+	}
+
+	res := sanitize.HTML(b.String(), base)
+	if len(strings.TrimSpace(sanitize.Text(res, "", 0))) < minArticleLength {
+		return "", fmt.Errorf("Couldn't find the article on the page")
+	}
+
+	return res, nil
+}
+
+func find(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if res := find(c, a); res != nil {
+			return res
+		}
+	}
+
+	return nil
+}
+
+// clean removes comments, clutter elements and elements whose class or id
+// name them as something else than content.
+func clean(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+
+		switch {
+		case c.Type == html.CommentNode:
+			n.RemoveChild(c)
+		case c.Type != html.ElementNode:
+		case removed[c.DataAtom]:
+			n.RemoveChild(c)
+		case isUnlikely(c):
+			n.RemoveChild(c)
+		default:
+			clean(c)
+		}
+
+		c = next
+	}
+}
+
+func isUnlikely(n *html.Node) bool {
+	if n.DataAtom == atom.Body || n.DataAtom == atom.A || n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+
+	names := attr(n, "class") + " " + attr(n, "id")
+	if attr(n, "role") == "complementary" || attr(n, "role") == "navigation" {
+		return true
+	}
+
+	return unlikely.MatchString(names) && !maybe.MatchString(names)
+}
+
+// classWeight rewards class names and ids of content, penalizes those of
+// clutter.
+func classWeight(n *html.Node) float64 {
+	var res float64
+
+	for _, v := range []string{attr(n, "class"), attr(n, "id")} {
+		if v == "" {
+			continue
+		}
+
+		if negative.MatchString(v) {
+			res -= 25
+		}
+
+		if positive.MatchString(v) {
+			res += 25
+		}
+	}
+
+	return res
+}
+
+func tagWeight(n *html.Node) float64 {
+	switch n.DataAtom {
+	case atom.Article:
+		return 10
+	case atom.Div, atom.Main, atom.Section:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+
+	return 0
+}
+
+// topCandidate scores the ancestors of every paragraph and returns the best
+// one, weighted down by how much of its text is links.
+func topCandidate(body *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	// candidates keeps document order, so ties always go the same way.
+	var candidates []*html.Node
+
+	score := func(n *html.Node, add float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+
+		if _, ok := scores[n]; !ok {
+			scores[n] = tagWeight(n) + classWeight(n)
+			candidates = append(candidates, n)
+		}
+		scores[n] += add
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Td) {
+			text := textOf(n)
+			if len(text) >= 25 {
+				add := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				score(n.Parent, add)
+				if n.Parent != nil {
+					score(n.Parent.Parent, add/2)
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(body)
+
+	var top *html.Node
+	var best float64
+
+	for _, n := range candidates {
+		v := scores[n] * (1 - linkDensity(n))
+
+		if top == nil || v > best {
+			top, best = n, v
+		}
+	}
+
+	return top
+}
+
+// withSiblings returns top and the siblings sharing its class or reading like
+// paragraphs of the same article.
+func withSiblings(top *html.Node) []*html.Node {
+	if top.Parent == nil {
+		return []*html.Node{top}
+	}
+
+	var res []*html.Node
+	top_class := attr(top, "class")
+
+	for c := top.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c == top {
+			res = append(res, c)
+			continue
+		}
+
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		if top_class != "" && attr(c, "class") == top_class {
+			res = append(res, c)
+			continue
+		}
+
+		if c.DataAtom == atom.P {
+			text := textOf(c)
+			density := linkDensity(c)
+
+			if (len(text) > 80 && density < 0.25) || (len(text) > 0 && density == 0 && strings.ContainsAny(text, ".!?")) {
+				res = append(res, c)
+			}
+		}
+	}
+
+	return res
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := len(textOf(n))
+	if total == 0 {
+		return 0
+	}
+
+	links := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			links += len(textOf(n))
+			return
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return float64(links) / float64(total)
+}
+
+func textOf(n *html.Node) string {
+	var b strings.Builder
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, v := range n.Attr {
+		if v.Key == key && v.Namespace == "" {
+			return v.Val
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,508 @@
+package backup
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"time"
+	"bufio"
+	"errors"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// An archive is a gzip-compressed JSON Lines file: a header line naming the
+// format and its version, then one line per row, users before feeds before
+// follows and posts, so it can be loaded in a single pass. Columns are spelled
+// out independently of the database models to keep old archives readable.
+//
+// Version 2 added categories and follow ids, version 3 tags, version 4
+// filters and hidden posts, version 5 full text articles.
+
+const (
+	archiveFormat  = "gator-backup"
+	archiveVersion = 5
+)
+
+type header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type record struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type userRecord struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+	ApiKey    *string   `json:"api_key,omitempty"`
+	Role      string    `json:"role,omitempty"`
+}
+
+type feedRecord struct {
+	ID                int32      `json:"id"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	Name              string     `json:"name"`
+	Url               string     `json:"url"`
+	UserID            uuid.UUID  `json:"user_id"`
+	LastFetchedAt     *time.Time `json:"last_fetched_at,omitempty"`
+	NextFetchAt       *time.Time `json:"next_fetch_at,omitempty"`
+	RetentionDays     *int32     `json:"retention_days,omitempty"`
+	RetentionMaxPosts *int32     `json:"retention_max_posts,omitempty"`
+	Title             string     `json:"title,omitempty"`
+	SiteLink          string     `json:"site_link,omitempty"`
+	Description       string     `json:"description,omitempty"`
+	Language          string     `json:"language,omitempty"`
+	ImageUrl          string     `json:"image_url,omitempty"`
+	Generator         string     `json:"generator,omitempty"`
+	FullText          bool       `json:"full_text,omitempty"`
+}
+
+type followRecord struct {
+	ID        int32     `json:"id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    int32     `json:"feed_id"`
+}
+
+type postRecord struct {
+	ID          int32     `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Title       string    `json:"title"`
+	Url         string    `json:"url"`
+	Description string    `json:"description"`
+	PublishedAt time.Time `json:"published_at"`
+	FeedID      int32     `json:"feed_id"`
+}
+
+type categoryRecord struct {
+	ID        int32     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+}
+
+type followCategoryRecord struct {
+	FeedFollowID int32 `json:"feed_follow_id"`
+	CategoryID   int32 `json:"category_id"`
+}
+
+type tagRecord struct {
+	ID        int32     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+}
+
+type postTagRecord struct {
+	PostID int32 `json:"post_id"`
+	TagID  int32 `json:"tag_id"`
+}
+
+type postContentRecord struct {
+	PostID    int32     `json:"post_id"`
+	Content   string    `json:"content"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+type postStateRecord struct {
+	UserID    uuid.UUID `json:"user_id"`
+	PostID    int32     `json:"post_id"`
+	Read      bool      `json:"read"`
+	Starred   bool      `json:"starred"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Hidden    bool      `json:"hidden,omitempty"`
+}
+
+type filterRecord struct {
+	ID        int32     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	FeedID    *int32    `json:"feed_id,omitempty"`
+	Field     string    `json:"field"`
+	Pattern   string    `json:"pattern"`
+	Action    string    `json:"action"`
+}
+
+// WriteFile writes the snapshot as an archive to path, which must not exist.
+// A partly written file is removed again.
+func (s Snapshot) WriteFile(path string) (err error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if c_err := file.Close(); err == nil {
+			err = c_err
+		}
+
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	gz := gzip.NewWriter(file)
+	enc := json.NewEncoder(gz)
+
+	if err := enc.Encode(header{Format: archiveFormat, Version: archiveVersion, CreatedAt: s.CreatedAt}); err != nil {
+		return err
+	}
+
+	write := func(kind string, data any) error {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		return enc.Encode(record{Type: kind, Data: raw})
+	}
+
+	for _, v := range s.Users {
+		rec := userRecord{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			Name:      v.Name,
+			ApiKey:    fromNullString(v.ApiKey),
+			Role:      v.Role,
+		}
+
+		if err := write("user", rec); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.Feeds {
+		rec := feedRecord{
+			ID:                v.ID,
+			CreatedAt:         v.CreatedAt,
+			UpdatedAt:         v.UpdatedAt,
+			Name:              v.Name,
+			Url:               v.Url,
+			UserID:            v.UserID,
+			LastFetchedAt:     fromNullTime(v.LastFetchedAt),
+			NextFetchAt:       fromNullTime(v.NextFetchAt),
+			RetentionDays:     fromNullInt32(v.RetentionDays),
+			RetentionMaxPosts: fromNullInt32(v.RetentionMaxPosts),
+			Title:             v.Title,
+			SiteLink:          v.SiteLink,
+			Description:       v.Description,
+			Language:          v.Language,
+			ImageUrl:          v.ImageUrl,
+			Generator:         v.Generator,
+			FullText:          v.FullText,
+		}
+
+		if err := write("feed", rec); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.Follows {
+		rec := followRecord{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			UserID:    v.UserID,
+			FeedID:    v.FeedID,
+		}
+
+		if err := write("follow", rec); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.Posts {
+		if err := write("post", postRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.PostStates {
+		if err := write("post_state", postStateRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.Categories {
+		if err := write("category", categoryRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.FollowCategories {
+		if err := write("follow_category", followCategoryRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.Tags {
+		if err := write("tag", tagRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.PostTags {
+		if err := write("post_tag", postTagRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.PostContents {
+		if err := write("post_content", postContentRecord(v)); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range s.Filters {
+		rec := filterRecord{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			UserID:    v.UserID,
+			FeedID:    fromNullInt32(v.FeedID),
+			Field:     v.Field,
+			Pattern:   v.Pattern,
+			Action:    v.Action,
+		}
+
+		if err := write("filter", rec); err != nil {
+			return err
+		}
+	}
+
+	return gz.Close()
+}
+
+// ReadFile loads an archive written by WriteFile.
+func ReadFile(path string) (Snapshot, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("Not a gator backup - %w", err)
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(gz))
+
+	var head header
+	if err := dec.Decode(&head); err != nil || head.Format != archiveFormat {
+		return Snapshot{}, fmt.Errorf("Not a gator backup - %s", path)
+	}
+
+	if head.Version > archiveVersion {
+		return Snapshot{}, fmt.Errorf("Backup version %d is newer than supported version %d, upgrade gator", head.Version, archiveVersion)
+	}
+
+	res := Snapshot{CreatedAt: head.CreatedAt}
+
+	for line := 2; ; line++ {
+		var rec record
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return Snapshot{}, fmt.Errorf("Broken backup at line %d - %w", line, err)
+		}
+
+		if err := res.add(rec); err != nil {
+			return Snapshot{}, fmt.Errorf("Broken backup at line %d - %w", line, err)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Snapshot) add(rec record) error {
+	switch rec.Type {
+	case "user":
+		var v userRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Users = append(s.Users, database.User{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			Name:      v.Name,
+			ApiKey:    toNullString(v.ApiKey),
+			Role:      v.Role,
+		})
+	case "feed":
+		var v feedRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Feeds = append(s.Feeds, database.Feed{
+			ID:                v.ID,
+			CreatedAt:         v.CreatedAt,
+			UpdatedAt:         v.UpdatedAt,
+			Name:              v.Name,
+			Url:               v.Url,
+			UserID:            v.UserID,
+			LastFetchedAt:     toNullTime(v.LastFetchedAt),
+			NextFetchAt:       toNullTime(v.NextFetchAt),
+			RetentionDays:     toNullInt32(v.RetentionDays),
+			RetentionMaxPosts: toNullInt32(v.RetentionMaxPosts),
+			Title:             v.Title,
+			SiteLink:          v.SiteLink,
+			Description:       v.Description,
+			Language:          v.Language,
+			ImageUrl:          v.ImageUrl,
+			Generator:         v.Generator,
+			FullText:          v.FullText,
+		})
+	case "follow":
+		var v followRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Follows = append(s.Follows, database.FeedFollow{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			UserID:    v.UserID,
+			FeedID:    v.FeedID,
+		})
+	case "post":
+		var v postRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Posts = append(s.Posts, database.Post(v))
+	case "post_state":
+		var v postStateRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.PostStates = append(s.PostStates, database.PostState(v))
+	case "category":
+		var v categoryRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Categories = append(s.Categories, database.Category(v))
+	case "follow_category":
+		var v followCategoryRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.FollowCategories = append(s.FollowCategories, database.FeedFollowCategory(v))
+	case "tag":
+		var v tagRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Tags = append(s.Tags, database.Tag(v))
+	case "post_tag":
+		var v postTagRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.PostTags = append(s.PostTags, database.PostTag(v))
+	case "post_content":
+		var v postContentRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.PostContents = append(s.PostContents, database.PostContent(v))
+	case "filter":
+		var v filterRecord
+		if err := json.Unmarshal(rec.Data, &v); err != nil {
+			return err
+		}
+
+		s.Filters = append(s.Filters, database.Filter{
+			ID:        v.ID,
+			CreatedAt: v.CreatedAt,
+			UpdatedAt: v.UpdatedAt,
+			UserID:    v.UserID,
+			FeedID:    toNullInt32(v.FeedID),
+			Field:     v.Field,
+			Pattern:   v.Pattern,
+			Action:    v.Action,
+		})
+	default:
+		return fmt.Errorf("Unknown record type - %s", rec.Type)
+	}
+
+	return nil
+}
+
+func fromNullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.String
+}
+
+func toNullString(v *string) sql.NullString {
+	if v == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: *v, Valid: true}
+}
+
+func fromNullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Time
+}
+
+func toNullTime(v *time.Time) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+
+	return sql.NullTime{Time: *v, Valid: true}
+}
+
+func fromNullInt32(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+
+	return &v.Int32
+}
+
+func toNullInt32(v *int32) sql.NullInt32 {
+	if v == nil {
+		return sql.NullInt32{}
+	}
+
+	return sql.NullInt32{Int32: *v, Valid: true}
+}
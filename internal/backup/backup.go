@@ -0,0 +1,87 @@
+package backup
+
+import (
+	"time"
+	"context"
+	"gator/internal/database"
+)
+
+// Snapshot holds every table of the database, to be written to an archive or
+// restored from one.
+type Snapshot struct {
+	CreatedAt  time.Time
+	Users      []database.User
+	Feeds      []database.Feed
+	Follows    []database.FeedFollow
+	Posts      []database.Post
+	PostStates []database.PostState
+	// PostContents holds the full text articles fetched for posts.
+	PostContents []database.PostContent
+	Categories   []database.Category
+	// FollowCategories maps follows to categories.
+	FollowCategories []database.FeedFollowCategory
+	Tags             []database.Tag
+	// PostTags maps posts to tags.
+	PostTags []database.PostTag
+	Filters  []database.Filter
+}
+
+// Take reads every table in one snapshot, so a backup taken while agg runs
+// has no rows whose parents were read at another moment.
+func Take(ctx context.Context, db database.Store) (Snapshot, error) {
+	res := Snapshot{CreatedAt: time.Now()}
+
+	err := db.InSnapshot(ctx, func(qtx database.Store) error {
+		var err error
+		if res.Users, err = qtx.GetAllUsers(ctx); err != nil {
+			return err
+		}
+
+		if res.Feeds, err = qtx.GetFeeds(ctx); err != nil {
+			return err
+		}
+
+		if res.Follows, err = qtx.GetAllFeedFollows(ctx); err != nil {
+			return err
+		}
+
+		if res.Posts, err = qtx.GetAllPosts(ctx); err != nil {
+			return err
+		}
+
+		if res.PostStates, err = qtx.GetAllPostStates(ctx); err != nil {
+			return err
+		}
+
+		if res.PostContents, err = qtx.GetAllPostContents(ctx); err != nil {
+			return err
+		}
+
+		if res.Categories, err = qtx.GetAllCategories(ctx); err != nil {
+			return err
+		}
+
+		if res.FollowCategories, err = qtx.GetAllFeedFollowCategories(ctx); err != nil {
+			return err
+		}
+
+		if res.Tags, err = qtx.GetAllTags(ctx); err != nil {
+			return err
+		}
+
+		if res.PostTags, err = qtx.GetAllPostTags(ctx); err != nil {
+			return err
+		}
+
+		if res.Filters, err = qtx.GetAllFilters(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	return res, nil
+}
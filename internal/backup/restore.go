@@ -0,0 +1,262 @@
+package backup
+
+import (
+	"fmt"
+	"context"
+	"database/sql"
+	"gator/internal/database"
+)
+
+// Restore loads the snapshot into an empty database in one transaction.
+// Feeds, follows, posts, categories, tags and filters get new ids from the
+// target database and every reference to them is remapped, user ids are kept
+// as they are.
+func Restore(ctx context.Context, db database.Store, snap Snapshot) error {
+	return db.InTx(ctx, func(qtx database.Store) error {
+		users, err := qtx.GetAllUsers(ctx)
+		if err != nil {
+			return err
+		}
+
+		feeds, err := qtx.GetFeeds(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(users) > 0 || len(feeds) > 0 {
+			return fmt.Errorf("Restore needs an empty database, run reset first")
+		}
+
+		// Archives from before roles existed have none, the oldest user
+		// becomes admin as the migration adding roles does.
+		has_admin := false
+		for _, v := range snap.Users {
+			has_admin = has_admin || v.Role == database.RoleAdmin
+		}
+
+		for i, v := range snap.Users {
+			role := v.Role
+			if role == "" {
+				role = database.RoleMember
+			}
+
+			if i == 0 && !has_admin {
+				role = database.RoleAdmin
+			}
+
+			user_params := database.RestoreUserParams{
+				ID:        v.ID,
+				CreatedAt: v.CreatedAt,
+				UpdatedAt: v.UpdatedAt,
+				Name:      v.Name,
+				ApiKey:    v.ApiKey,
+				Role:      role,
+			}
+
+			if err := qtx.RestoreUser(ctx, user_params); err != nil {
+				return fmt.Errorf("Error restoring user %s - %w", v.Name, err)
+			}
+		}
+
+		feed_ids := make(map[int32]int32, len(snap.Feeds))
+		for _, v := range snap.Feeds {
+			feed_params := database.RestoreFeedParams{
+				CreatedAt:         v.CreatedAt,
+				UpdatedAt:         v.UpdatedAt,
+				Name:              v.Name,
+				Url:               v.Url,
+				UserID:            v.UserID,
+				LastFetchedAt:     v.LastFetchedAt,
+				NextFetchAt:       v.NextFetchAt,
+				RetentionDays:     v.RetentionDays,
+				RetentionMaxPosts: v.RetentionMaxPosts,
+				Title:             v.Title,
+				SiteLink:          v.SiteLink,
+				Description:       v.Description,
+				Language:          v.Language,
+				ImageUrl:          v.ImageUrl,
+				Generator:         v.Generator,
+				FullText:          v.FullText,
+			}
+
+			id, err := qtx.RestoreFeed(ctx, feed_params)
+			if err != nil {
+				return fmt.Errorf("Error restoring feed %s - %w", v.Url, err)
+			}
+
+			feed_ids[v.ID] = id
+		}
+
+		follow_ids := make(map[int32]int32, len(snap.Follows))
+		for _, v := range snap.Follows {
+			feed_id, ok := feed_ids[v.FeedID]
+			if !ok {
+				return fmt.Errorf("Follow of unknown feed %d", v.FeedID)
+			}
+
+			follow_params := database.CreateFeedFollowParams{
+				CreatedAt: v.CreatedAt,
+				UpdatedAt: v.UpdatedAt,
+				UserID:    v.UserID,
+				FeedID:    feed_id,
+			}
+
+			follow, err := qtx.CreateFeedFollow(ctx, follow_params)
+			if err != nil {
+				return fmt.Errorf("Error restoring a follow of feed %d - %w", v.FeedID, err)
+			}
+
+			follow_ids[v.ID] = follow.ID
+		}
+
+		post_ids := make(map[int32]int32, len(snap.Posts))
+		for _, v := range snap.Posts {
+			feed_id, ok := feed_ids[v.FeedID]
+			if !ok {
+				return fmt.Errorf("Post %s of unknown feed %d", v.Url, v.FeedID)
+			}
+
+			post_params := database.RestorePostParams{
+				CreatedAt:   v.CreatedAt,
+				UpdatedAt:   v.UpdatedAt,
+				Title:       v.Title,
+				Url:         v.Url,
+				Description: v.Description,
+				PublishedAt: v.PublishedAt,
+				FeedID:      feed_id,
+			}
+
+			id, err := qtx.RestorePost(ctx, post_params)
+			if err != nil {
+				return fmt.Errorf("Error restoring post %s - %w", v.Url, err)
+			}
+
+			post_ids[v.ID] = id
+		}
+
+		for _, v := range snap.PostStates {
+			post_id, ok := post_ids[v.PostID]
+			if !ok {
+				return fmt.Errorf("State of unknown post %d", v.PostID)
+			}
+
+			state_params := database.RestorePostStateParams{
+				UserID:    v.UserID,
+				PostID:    post_id,
+				Read:      v.Read,
+				Starred:   v.Starred,
+				UpdatedAt: v.UpdatedAt,
+				Hidden:    v.Hidden,
+			}
+
+			if err := qtx.RestorePostState(ctx, state_params); err != nil {
+				return fmt.Errorf("Error restoring state of post %d - %w", v.PostID, err)
+			}
+		}
+
+		for _, v := range snap.PostContents {
+			post_id, ok := post_ids[v.PostID]
+			if !ok {
+				return fmt.Errorf("Article of unknown post %d", v.PostID)
+			}
+
+			content_params := database.SetPostContentParams{
+				PostID:    post_id,
+				Content:   v.Content,
+				FetchedAt: v.FetchedAt,
+			}
+
+			if err := qtx.SetPostContent(ctx, content_params); err != nil {
+				return fmt.Errorf("Error restoring article of post %d - %w", v.PostID, err)
+			}
+		}
+
+		category_ids := make(map[int32]int32, len(snap.Categories))
+		for _, v := range snap.Categories {
+			cat_params := database.CreateCategoryParams{
+				CreatedAt: v.CreatedAt,
+				UpdatedAt: v.UpdatedAt,
+				UserID:    v.UserID,
+				Name:      v.Name,
+			}
+
+			category, err := qtx.CreateCategory(ctx, cat_params)
+			if err != nil {
+				return fmt.Errorf("Error restoring category %s - %w", v.Name, err)
+			}
+
+			category_ids[v.ID] = category.ID
+		}
+
+		for _, v := range snap.FollowCategories {
+			follow_id, ok := follow_ids[v.FeedFollowID]
+			category_id, cat_ok := category_ids[v.CategoryID]
+			if !ok || !cat_ok {
+				return fmt.Errorf("Category %d of unknown follow %d", v.CategoryID, v.FeedFollowID)
+			}
+
+			link_params := database.CategorizeFollowParams{
+				FeedFollowID: follow_id,
+				CategoryID:   category_id,
+			}
+
+			if err := qtx.CategorizeFollow(ctx, link_params); err != nil {
+				return err
+			}
+		}
+
+		tag_ids := make(map[int32]int32, len(snap.Tags))
+		for _, v := range snap.Tags {
+			tag_params := database.CreateTagParams{
+				CreatedAt: v.CreatedAt,
+				UpdatedAt: v.UpdatedAt,
+				UserID:    v.UserID,
+				Name:      v.Name,
+			}
+
+			tag, err := qtx.CreateTag(ctx, tag_params)
+			if err != nil {
+				return fmt.Errorf("Error restoring tag %s - %w", v.Name, err)
+			}
+
+			tag_ids[v.ID] = tag.ID
+		}
+
+		for _, v := range snap.PostTags {
+			post_id, ok := post_ids[v.PostID]
+			tag_id, tag_ok := tag_ids[v.TagID]
+			if !ok || !tag_ok {
+				return fmt.Errorf("Tag %d of unknown post %d", v.TagID, v.PostID)
+			}
+
+			if err := qtx.TagPost(ctx, database.TagPostParams{PostID: post_id, TagID: tag_id}); err != nil {
+				return err
+			}
+		}
+
+		for _, v := range snap.Filters {
+			filter_params := database.CreateFilterParams{
+				CreatedAt: v.CreatedAt,
+				UpdatedAt: v.UpdatedAt,
+				UserID:    v.UserID,
+				Field:     v.Field,
+				Pattern:   v.Pattern,
+				Action:    v.Action,
+			}
+
+			if v.FeedID.Valid {
+				feed_id, ok := feed_ids[v.FeedID.Int32]
+				if !ok {
+					return fmt.Errorf("Filter %d of unknown feed %d", v.ID, v.FeedID.Int32)
+				}
+				filter_params.FeedID = sql.NullInt32{Int32: feed_id, Valid: true}
+			}
+
+			if _, err := qtx.CreateFilter(ctx, filter_params); err != nil {
+				return fmt.Errorf("Error restoring filter %d - %w", v.ID, err)
+			}
+		}
+
+		return nil
+	})
+}
@@ -2,70 +2,487 @@ package config
 
 import (
 	"os"
+	"fmt"
+	"sort"
+	"time"
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"net/url"
+	"path/filepath"
 	"encoding/json"
+	"gator/internal/fetch"
+
+	"github.com/lib/pq"
 )
 
-const configFileName = ".gatorconfig.json"
+const (
+	// DefaultProfile names the db_url and current_user_name at the top of the
+	// file, used when no other profile is chosen.
+	DefaultProfile = "default"
+
+	configFileName       = "config.json"
+	legacyConfigFileName = ".gatorconfig.json"
+	backupDirName        = "backups"
+)
 
 type Config struct {
+	DB_URL              string `json:"db_url"`
+	Curr_Username       string `json:"current_user_name"`
+	Min_Fetch_Interval  string `json:"min_fetch_interval,omitempty"`
+	Max_Fetch_Interval  string `json:"max_fetch_interval,omitempty"`
+	Retention_Days      int32  `json:"retention_days,omitempty"`
+	Retention_Max_Posts int32  `json:"retention_max_posts,omitempty"`
+
+	// Connect_Timeout, Read_Timeout, Max_Body_Size, Proxy and Contact_URL
+	// configure every download, see fetch.Options.
+	Connect_Timeout string `json:"connect_timeout,omitempty"`
+	Read_Timeout    string `json:"read_timeout,omitempty"`
+	Max_Body_Size   int64  `json:"max_body_size,omitempty"`
+	Proxy           string `json:"proxy,omitempty"`
+	Contact_URL     string `json:"contact_url,omitempty"`
+	// Block_Private_Addresses refuses feeds on internal addresses but those
+	// of Allowed_Hosts, for a gator shared with untrusted users.
+	Block_Private_Addresses bool     `json:"block_private_addresses,omitempty"`
+	Allowed_Hosts           []string `json:"allowed_hosts,omitempty"`
+
+	// Profile is the profile used when --profile isn't given, Profiles
+	// holds every profile besides the default one.
+	Profile  string             `json:"profile,omitempty"`
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// path is the file the config was read from and is written back to.
+	path string
+	// active is the profile in use, its settings are copied to DB_URL and
+	// Curr_Username while the ones of the default profile are kept in base.
+	active string
+	base   Profile
+	// file_db_url is the db_url of the file when GATOR_DB_URL overrides it,
+	// so the override is never written back.
+	file_db_url string
+	overridden  bool
+}
+
+// Profile is one database with the user logged in to it.
+type Profile struct {
 	DB_URL        string `json:"db_url"`
 	Curr_Username string `json:"current_user_name"`
 }
 
-func Read() (Config, error) {
-	cfg_path, err := getConfigFilePath()
+// New returns an empty config that is saved to path.
+func New(path string) Config {
+	return Config{path: path}
+}
+
+// Load reads the config from path, or from FilePath when path is empty,
+// switches to profile, or else the profile the file selects, and applies the
+// GATOR_DB_URL override.
+func Load(path, profile string) (Config, error) {
+	cfg_path, err := FilePath(path)
 	if err != nil {
 		return Config{}, err
 	}
 
 	data, err := os.ReadFile(cfg_path)
-	if err != nil {
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("No config at %s, create one with 'gator init'", cfg_path)
+	} else if err != nil {
 		return Config{}, err
 	}
 
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
 	var res_cfg Config
-	if err := json.Unmarshal(data, &res_cfg); err != nil {
-		return Config{}, err
+	if err := dec.Decode(&res_cfg); err != nil {
+		return Config{}, fmt.Errorf("Invalid config %s - %w", cfg_path, err)
+	}
+
+	res_cfg.path = cfg_path
+	res_cfg.base = Profile{DB_URL: res_cfg.DB_URL, Curr_Username: res_cfg.Curr_Username}
+
+	if profile == "" {
+		profile = res_cfg.Profile
+	}
+
+	if profile != "" && profile != DefaultProfile {
+		prof, ok := res_cfg.Profiles[profile]
+		if !ok {
+			return Config{}, fmt.Errorf("Unknown profile %s in %s, see 'gator profile list'", profile, cfg_path)
+		}
+
+		res_cfg.active = profile
+		res_cfg.DB_URL, res_cfg.Curr_Username = prof.DB_URL, prof.Curr_Username
+	}
+
+	if db_url := os.Getenv("GATOR_DB_URL"); db_url != "" {
+		res_cfg.file_db_url, res_cfg.overridden = res_cfg.DB_URL, true
+		res_cfg.DB_URL = db_url
+	}
+
+	if err := res_cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("Invalid config %s - %w", cfg_path, err)
 	}
 
 	return res_cfg, nil
 }
 
+// Validate checks every setting, so a typo fails at startup rather than
+// being ignored.
+func (c Config) Validate() error {
+	if c.DB_URL == "" {
+		return fmt.Errorf("db_url is required")
+	}
+
+	if !validDBURL(c.DB_URL) {
+		return fmt.Errorf("db_url must be a postgres://, postgresql:// or sqlite:// URL or key=value connection string - %s", redact(c.DB_URL))
+	}
+
+	var min_fetch, max_fetch time.Duration
+	for _, v := range []struct {
+		key   string
+		value string
+		res   *time.Duration
+	}{
+		{"min_fetch_interval", c.Min_Fetch_Interval, &min_fetch},
+		{"max_fetch_interval", c.Max_Fetch_Interval, &max_fetch},
+		{"connect_timeout", c.Connect_Timeout, new(time.Duration)},
+		{"read_timeout", c.Read_Timeout, new(time.Duration)},
+	} {
+		if v.value == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(v.value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration like 15m or 24h - %s", v.key, v.value)
+		}
+		*v.res = d
+	}
+
+	if min_fetch > 0 && max_fetch > 0 && min_fetch > max_fetch {
+		return fmt.Errorf("min_fetch_interval %s is longer than max_fetch_interval %s", c.Min_Fetch_Interval, c.Max_Fetch_Interval)
+	}
+
+	if c.Retention_Days < 0 || c.Retention_Max_Posts < 0 {
+		return fmt.Errorf("retention_days and retention_max_posts must be 0 or more")
+	}
+
+	if c.Max_Body_Size < 0 {
+		return fmt.Errorf("max_body_size must be 0 or more")
+	}
+
+	if c.Proxy != "" {
+		if _, err := fetch.ParseProxy(c.Proxy); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range c.Allowed_Hosts {
+		if err := fetch.ParseAllowed(v); err != nil {
+			return err
+		}
+	}
+
+	if c.Contact_URL != "" {
+		contact, err := url.Parse(c.Contact_URL)
+		if err != nil || (contact.Scheme != "http" && contact.Scheme != "https") || contact.Host == "" {
+			return fmt.Errorf("contact_url must be an http or https URL - %s", c.Contact_URL)
+		}
+	}
+
+	if _, ok := c.Profiles[DefaultProfile]; ok {
+		return fmt.Errorf("profile %s is the db_url at the top of the file and can't be in profiles", DefaultProfile)
+	}
+
+	if c.Profile != "" && c.Profile != DefaultProfile {
+		if _, ok := c.Profiles[c.Profile]; !ok {
+			return fmt.Errorf("profile %s is not in profiles", c.Profile)
+		}
+	}
+
+	for name, v := range c.Profiles {
+		if !validDBURL(v.DB_URL) {
+			return fmt.Errorf("db_url of profile %s must be a postgres://, postgresql:// or sqlite:// URL or key=value connection string - %s", name, redact(v.DB_URL))
+		}
+	}
+
+	return nil
+}
+
+var (
+	// dsnKey starts a lib/pq connection string, "host=localhost dbname=gator".
+	dsnKey = regexp.MustCompile(`^\s*[A-Za-z_]+\s*=`)
+	// dsnPassword is the password of a connection string, quoted or not.
+	dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S*)`)
+)
+
+// validDBURL accepts a sqlite:// URL and whatever lib/pq connects with, a
+// postgres:// or postgresql:// URL or a key=value connection string.
+func validDBURL(db_url string) bool {
+	if strings.HasPrefix(db_url, "sqlite://") {
+		return true
+	}
+
+	if strings.Contains(db_url, "://") {
+		_, err := pq.ParseURL(db_url)
+		return err == nil
+	}
+
+	return dsnKey.MatchString(db_url)
+}
+
+// redact hides the password of a database URL in error messages.
+func redact(db_url string) string {
+	scheme, rest, ok := strings.Cut(db_url, "://")
+	if !ok {
+		if dsnKey.MatchString(db_url) {
+			return dsnPassword.ReplaceAllString(db_url, "${1}***")
+		}
+
+		return "<hidden>"
+	}
+
+	creds, host, ok := strings.Cut(rest, "@")
+	if !ok {
+		return db_url
+	}
+
+	user, _, _ := strings.Cut(creds, ":")
+
+	return scheme + "://" + user + ":***@" + host
+}
+
+// FetchOptions returns the download settings, unset ones left for fetch.New
+// to default.
+func (c Config) FetchOptions() fetch.Options {
+	// Validate already rejected unparsable timeouts.
+	connect, _ := time.ParseDuration(c.Connect_Timeout)
+	read, _ := time.ParseDuration(c.Read_Timeout)
+
+	return fetch.Options{
+		ConnectTimeout: connect,
+		ReadTimeout:    read,
+		MaxBodySize:    c.Max_Body_Size,
+		Proxy:          c.Proxy,
+		ContactURL:     c.Contact_URL,
+		Guard:          c.Block_Private_Addresses,
+		AllowedHosts:   c.Allowed_Hosts,
+	}
+}
+
 func (c *Config) SetUser(new_name string) error {
 	c.Curr_Username = new_name
 
-	if err := write(*c); err != nil {
+	if err := c.Save(); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func getConfigFilePath() (string, error) {
-	sub_path, err := os.UserHomeDir()
+// ActiveProfile returns the name of the profile in use.
+func (c *Config) ActiveProfile() string {
+	if c.active == "" {
+		return DefaultProfile
+	}
+
+	return c.active
+}
+
+// ProfileNames returns every profile, the default one first.
+func (c *Config) ProfileNames() []string {
+	res := make([]string, 0, len(c.Profiles)+1)
+	for name := range c.Profiles {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+
+	return append([]string{DefaultProfile}, res...)
+}
+
+// ProfileURL returns the database URL of a profile as stored in the file,
+// with the password hidden.
+func (c *Config) ProfileURL(name string) string {
+	if name == DefaultProfile {
+		return redact(c.base.DB_URL)
+	}
+
+	return redact(c.Profiles[name].DB_URL)
+}
+
+// UseProfile makes name the profile used when --profile isn't given.
+func (c *Config) UseProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok && name != DefaultProfile {
+		return fmt.Errorf("Unknown profile - %s", name)
+	}
+
+	c.Profile = name
+	if name == DefaultProfile {
+		c.Profile = ""
+	}
+
+	return c.Save()
+}
+
+func (c *Config) AddProfile(name, db_url string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("Profile %s always exists", DefaultProfile)
+	}
+
+	if _, ok := c.Profiles[name]; ok {
+		return fmt.Errorf("Profile %s already exists", name)
+	}
+
+	if !validDBURL(db_url) {
+		return fmt.Errorf("Database URL must be a postgres://, postgresql:// or sqlite:// URL or key=value connection string - %s", redact(db_url))
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	c.Profiles[name] = Profile{DB_URL: db_url}
+
+	return c.Save()
+}
+
+func (c *Config) RemoveProfile(name string) error {
+	if name == DefaultProfile {
+		return fmt.Errorf("Profile %s can't be removed", DefaultProfile)
+	}
+
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("Unknown profile - %s", name)
+	}
+
+	if name == c.active {
+		return fmt.Errorf("Profile %s is in use, pick another one with --profile", name)
+	}
+
+	delete(c.Profiles, name)
+	if c.Profile == name {
+		c.Profile = ""
+	}
+
+	return c.Save()
+}
+
+// Path returns the file the config is saved to.
+func (c *Config) Path() string {
+	return c.path
+}
+
+// FilePath resolves which config file to use: path if given (the --config
+// flag), then $GATOR_CONFIG, then $XDG_CONFIG_HOME/gator/config.json, falling
+// back to ~/.gatorconfig.json when only that one exists.
+func FilePath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	if env_path := os.Getenv("GATOR_CONFIG"); env_path != "" {
+		return env_path, nil
+	}
+
+	config_dir, err := os.UserConfigDir()
 	if err != nil {
 		return "", err
 	}
 
-	res_path := sub_path + "/" + configFileName
+	res_path := filepath.Join(config_dir, "gator", configFileName)
+	if _, err := os.Stat(res_path); err == nil {
+		return res_path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	legacy_path := filepath.Join(home, legacyConfigFileName)
+	if _, err := os.Stat(legacy_path); err == nil {
+		return legacy_path, nil
+	}
 
 	return res_path, nil
 }
 
-func write(cfg Config) error {
-	cfg_path, err := getConfigFilePath()
+// BackupDir is where backups taken before destructive commands are kept,
+// $XDG_STATE_HOME/gator/backups.
+func BackupDir() (string, error) {
+	state_dir := os.Getenv("XDG_STATE_HOME")
+	if state_dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		state_dir = filepath.Join(home, ".local", "state")
+	}
+
+	return filepath.Join(state_dir, "gator", backupDirName), nil
+}
+
+// Save writes the config atomically and readable only by its owner, as it
+// holds the database credentials.
+func (c *Config) Save() error {
+	if c.path == "" {
+		return fmt.Errorf("Config has no file to be saved to")
+	}
+
+	db_url := c.DB_URL
+	if c.overridden {
+		db_url = c.file_db_url
+	}
+
+	file_cfg := *c
+	if c.active != "" {
+		file_cfg.Profiles = make(map[string]Profile, len(c.Profiles))
+		for name, v := range c.Profiles {
+			file_cfg.Profiles[name] = v
+		}
+
+		file_cfg.Profiles[c.active] = Profile{DB_URL: db_url, Curr_Username: c.Curr_Username}
+		file_cfg.DB_URL, file_cfg.Curr_Username = c.base.DB_URL, c.base.Curr_Username
+	} else {
+		file_cfg.DB_URL = db_url
+	}
+
+	new_data, err := json.MarshalIndent(file_cfg, "", "\t")
 	if err != nil {
 		return err
 	}
 
-	new_data, err := json.Marshal(cfg)
+	dir := filepath.Dir(c.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".gator-config-*")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name())
 
-	if err := os.WriteFile(cfg_path, new_data, 0644); err != nil {
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
 		return err
 	}
 
-	return nil
-} 
+	if _, err := tmp.Write(append(new_data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path)
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,7 +22,7 @@ VALUES(
 	$4,
 	$5
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text, parse_warnings
 `
 
 type CreateFeedParams struct {
@@ -49,12 +50,115 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.FullText,
+		&i.ParseWarnings,
+	)
+	return i, err
+}
+
+const deleteFeed = `-- name: DeleteFeed :execrows
+DELETE FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDueFeeds = `-- name: GetDueFeeds :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text, parse_warnings FROM feeds
+WHERE next_fetch_at IS NULL OR next_fetch_at <= $1
+ORDER BY next_fetch_at ASC NULLS FIRST
+`
+
+func (q *Queries) GetDueFeeds(ctx context.Context, nextFetchAt sql.NullTime) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getDueFeeds, nextFetchAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.RetentionDays,
+			&i.RetentionMaxPosts,
+			&i.Title,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.FullText,
+			&i.ParseWarnings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text, parse_warnings FROM feeds
+WHERE id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id int32) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.FullText,
+		&i.ParseWarnings,
 	)
 	return i, err
 }
 
 const getFeedByURL = `-- name: GetFeedByURL :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text, parse_warnings FROM feeds
 WHERE url = $1
 `
 
@@ -69,12 +173,23 @@ func (q *Queries) GetFeedByURL(ctx context.Context, url string) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.FullText,
+		&i.ParseWarnings,
 	)
 	return i, err
 }
 
 const getFeeds = `-- name: GetFeeds :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text, parse_warnings FROM feeds
 `
 
 func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
@@ -94,6 +209,128 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 			&i.Url,
 			&i.UserID,
 			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.RetentionDays,
+			&i.RetentionMaxPosts,
+			&i.Title,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.FullText,
+			&i.ParseWarnings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedsForUser = `-- name: GetFollowedFeedsForUser :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.next_fetch_at, feeds.retention_days, feeds.retention_max_posts, feeds.title, feeds.site_link, feeds.description, feeds.language, feeds.image_url, feeds.generator, feeds.full_text, feeds.parse_warnings
+FROM feeds
+INNER JOIN feed_follows
+ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.id
+`
+
+func (q *Queries) GetFollowedFeedsForUser(ctx context.Context, userID uuid.UUID) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.RetentionDays,
+			&i.RetentionMaxPosts,
+			&i.Title,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.FullText,
+			&i.ParseWarnings,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowedFeedsInCategory = `-- name: GetFollowedFeedsInCategory :many
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.next_fetch_at, feeds.retention_days, feeds.retention_max_posts, feeds.title, feeds.site_link, feeds.description, feeds.language, feeds.image_url, feeds.generator, feeds.full_text, feeds.parse_warnings
+FROM feeds
+INNER JOIN feed_follows
+ON feeds.id = feed_follows.feed_id
+INNER JOIN feed_follow_categories
+ON feed_follow_categories.feed_follow_id = feed_follows.id
+INNER JOIN categories
+ON feed_follow_categories.category_id = categories.id
+WHERE feed_follows.user_id = $1 AND categories.name = $2
+ORDER BY feeds.id
+`
+
+type GetFollowedFeedsInCategoryParams struct {
+	UserID uuid.UUID
+	Name   string
+}
+
+func (q *Queries) GetFollowedFeedsInCategory(ctx context.Context, arg GetFollowedFeedsInCategoryParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsInCategory, arg.UserID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.NextFetchAt,
+			&i.RetentionDays,
+			&i.RetentionMaxPosts,
+			&i.Title,
+			&i.SiteLink,
+			&i.Description,
+			&i.Language,
+			&i.ImageUrl,
+			&i.Generator,
+			&i.FullText,
+			&i.ParseWarnings,
 		); err != nil {
 			return nil, err
 		}
@@ -109,8 +346,8 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]Feed, error) {
 }
 
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text, parse_warnings FROM feeds
+ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
 LIMIT 1
 `
 
@@ -125,22 +362,221 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.NextFetchAt,
+		&i.RetentionDays,
+		&i.RetentionMaxPosts,
+		&i.Title,
+		&i.SiteLink,
+		&i.Description,
+		&i.Language,
+		&i.ImageUrl,
+		&i.Generator,
+		&i.FullText,
+		&i.ParseWarnings,
 	)
 	return i, err
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 UPDATE feeds
-SET updated_at = $2, last_fetched_at = $2
+SET updated_at = $2, last_fetched_at = $2, next_fetch_at = $3
 WHERE feeds.id = $1
 `
 
 type MarkFeedFetchedParams struct {
+	ID          int32
+	UpdatedAt   time.Time
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.UpdatedAt, arg.NextFetchAt)
+	return err
+}
+
+const renameFeed = `-- name: RenameFeed :exec
+UPDATE feeds
+SET updated_at = $2, name = $3
+WHERE id = $1
+`
+
+type RenameFeedParams struct {
 	ID        int32
 	UpdatedAt time.Time
+	Name      string
 }
 
-func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.ID, arg.UpdatedAt)
+func (q *Queries) RenameFeed(ctx context.Context, arg RenameFeedParams) error {
+	_, err := q.db.ExecContext(ctx, renameFeed, arg.ID, arg.UpdatedAt, arg.Name)
+	return err
+}
+
+const restoreFeed = `-- name: RestoreFeed :one
+INSERT INTO feeds(created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10,
+	$11,
+	$12,
+	$13,
+	$14,
+	$15,
+	$16
+)
+RETURNING id
+`
+
+type RestoreFeedParams struct {
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Name              string
+	Url               string
+	UserID            uuid.UUID
+	LastFetchedAt     sql.NullTime
+	NextFetchAt       sql.NullTime
+	RetentionDays     sql.NullInt32
+	RetentionMaxPosts sql.NullInt32
+	Title             string
+	SiteLink          string
+	Description       string
+	Language          string
+	ImageUrl          string
+	Generator         string
+	FullText          bool
+}
+
+func (q *Queries) RestoreFeed(ctx context.Context, arg RestoreFeedParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, restoreFeed,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Name,
+		arg.Url,
+		arg.UserID,
+		arg.LastFetchedAt,
+		arg.NextFetchAt,
+		arg.RetentionDays,
+		arg.RetentionMaxPosts,
+		arg.Title,
+		arg.SiteLink,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+		arg.Generator,
+		arg.FullText,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setFeedURL = `-- name: SetFeedURL :exec
+UPDATE feeds
+SET updated_at = $2, url = $3, last_fetched_at = NULL, next_fetch_at = NULL
+WHERE id = $1
+`
+
+type SetFeedURLParams struct {
+	ID        int32
+	UpdatedAt time.Time
+	Url       string
+}
+
+func (q *Queries) SetFeedURL(ctx context.Context, arg SetFeedURLParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedURL, arg.ID, arg.UpdatedAt, arg.Url)
+	return err
+}
+
+const setFeedFullText = `-- name: SetFeedFullText :exec
+UPDATE feeds
+SET updated_at = $2, full_text = $3
+WHERE id = $1
+`
+
+type SetFeedFullTextParams struct {
+	ID        int32
+	UpdatedAt time.Time
+	FullText  bool
+}
+
+func (q *Queries) SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFullText, arg.ID, arg.UpdatedAt, arg.FullText)
+	return err
+}
+
+const setFeedMetadata = `-- name: SetFeedMetadata :exec
+UPDATE feeds
+SET title = $2, site_link = $3, description = $4, language = $5, image_url = $6, generator = $7, parse_warnings = $8
+WHERE id = $1
+`
+
+type SetFeedMetadataParams struct {
+	ID            int32
+	Title         string
+	SiteLink      string
+	Description   string
+	Language      string
+	ImageUrl      string
+	Generator     string
+	ParseWarnings string
+}
+
+func (q *Queries) SetFeedMetadata(ctx context.Context, arg SetFeedMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedMetadata,
+		arg.ID,
+		arg.Title,
+		arg.SiteLink,
+		arg.Description,
+		arg.Language,
+		arg.ImageUrl,
+		arg.Generator,
+		arg.ParseWarnings,
+	)
+	return err
+}
+
+const setFeedNextFetch = `-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1
+`
+
+type SetFeedNextFetchParams struct {
+	ID          int32
+	NextFetchAt sql.NullTime
+}
+
+func (q *Queries) SetFeedNextFetch(ctx context.Context, arg SetFeedNextFetchParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedNextFetch, arg.ID, arg.NextFetchAt)
+	return err
+}
+
+const setFeedRetention = `-- name: SetFeedRetention :exec
+UPDATE feeds
+SET updated_at = $2, retention_days = $3, retention_max_posts = $4
+WHERE id = $1
+`
+
+type SetFeedRetentionParams struct {
+	ID                int32
+	UpdatedAt         time.Time
+	RetentionDays     sql.NullInt32
+	RetentionMaxPosts sql.NullInt32
+}
+
+func (q *Queries) SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedRetention,
+		arg.ID,
+		arg.UpdatedAt,
+		arg.RetentionDays,
+		arg.RetentionMaxPosts,
+	)
 	return err
 }
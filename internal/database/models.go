@@ -11,14 +11,33 @@ import (
 	"github.com/google/uuid"
 )
 
+type Category struct {
+	ID        int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type Feed struct {
-	ID            int32
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	ID                int32
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	Name              string
+	Url               string
+	UserID            uuid.UUID
+	LastFetchedAt     sql.NullTime
+	NextFetchAt       sql.NullTime
+	RetentionDays     sql.NullInt32
+	RetentionMaxPosts sql.NullInt32
+	Title             string
+	SiteLink          string
+	Description       string
+	Language          string
+	ImageUrl          string
+	Generator         string
+	FullText          bool
+	ParseWarnings     string
 }
 
 type FeedFollow struct {
@@ -29,6 +48,22 @@ type FeedFollow struct {
 	FeedID    int32
 }
 
+type FeedFollowCategory struct {
+	FeedFollowID int32
+	CategoryID   int32
+}
+
+type Filter struct {
+	ID        int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	FeedID    sql.NullInt32
+	Field     string
+	Pattern   string
+	Action    string
+}
+
 type Post struct {
 	ID          int32
 	CreatedAt   time.Time
@@ -40,9 +75,39 @@ type Post struct {
 	FeedID      int32
 }
 
+type PostContent struct {
+	PostID    int32
+	Content   string
+	FetchedAt time.Time
+}
+
+type PostState struct {
+	UserID    uuid.UUID
+	PostID    int32
+	Read      bool
+	Starred   bool
+	UpdatedAt time.Time
+	Hidden    bool
+}
+
+type PostTag struct {
+	PostID int32
+	TagID  int32
+}
+
+type Tag struct {
+	ID        int32
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
 type User struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Name      string
+	ApiKey    sql.NullString
+	Role      string
 }
@@ -8,9 +8,24 @@ package database
 import (
 	"context"
 	"time"
+
+	"github.com/google/uuid"
 )
 
-const createPost = `-- name: CreatePost :exec
+const countPostsForFeed = `-- name: CountPostsForFeed :one
+SELECT COUNT(*)
+FROM posts
+WHERE feed_id = $1
+`
+
+func (q *Queries) CountPostsForFeed(ctx context.Context, feedID int32) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsForFeed, feedID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createPost = `-- name: CreatePost :execrows
 INSERT INTO posts(created_at, updated_at, title, url, description, published_at, feed_id)
 VALUES(
 	$1,
@@ -21,6 +36,7 @@ VALUES(
 	$6,
 	$7
 )
+ON CONFLICT(url) DO NOTHING
 `
 
 type CreatePostParams struct {
@@ -33,8 +49,8 @@ type CreatePostParams struct {
 	FeedID      int32
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
-	_, err := q.db.ExecContext(ctx, createPost,
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPost,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.Title,
@@ -43,7 +59,203 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
 		arg.PublishedAt,
 		arg.FeedID,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePosts = `-- name: DeletePosts :execrows
+DELETE FROM posts
+`
+
+func (q *Queries) DeletePosts(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePosts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePostsBeyondLimit = `-- name: DeletePostsBeyondLimit :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1
+AND posts.id NOT IN (
+	SELECT kept.id FROM posts AS kept
+	WHERE kept.feed_id = $1
+	ORDER BY kept.published_at DESC, kept.id DESC
+	LIMIT $2
+)
+AND NOT EXISTS (
+	SELECT 1 FROM post_states
+	WHERE post_states.post_id = posts.id AND post_states.starred = TRUE
+)
+AND NOT EXISTS (
+	SELECT 1 FROM post_tags
+	WHERE post_tags.post_id = posts.id
+)
+`
+
+type DeletePostsBeyondLimitParams struct {
+	FeedID int32
+	Limit  int32
+}
+
+func (q *Queries) DeletePostsBeyondLimit(ctx context.Context, arg DeletePostsBeyondLimitParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePostsBeyondLimit, arg.FeedID, arg.Limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePostsPublishedBefore = `-- name: DeletePostsPublishedBefore :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1 AND posts.published_at < $2
+AND NOT EXISTS (
+	SELECT 1 FROM post_states
+	WHERE post_states.post_id = posts.id AND post_states.starred = TRUE
+)
+AND NOT EXISTS (
+	SELECT 1 FROM post_tags
+	WHERE post_tags.post_id = posts.id
+)
+`
+
+type DeletePostsPublishedBeforeParams struct {
+	FeedID      int32
+	PublishedAt time.Time
+}
+
+func (q *Queries) DeletePostsPublishedBefore(ctx context.Context, arg DeletePostsPublishedBeforeParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deletePostsPublishedBefore, arg.FeedID, arg.PublishedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAllPosts = `-- name: GetAllPosts :many
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id
+FROM posts
+ORDER BY id
+`
+
+func (q *Queries) GetAllPosts(ctx context.Context) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getAllPosts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedPostsForUser = `-- name: GetFeedPostsForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = $1
+WHERE posts.feed_id = $2 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $3
+`
+
+type GetFeedPostsForUserParams struct {
+	UserID uuid.UUID
+	FeedID int32
+	Limit  int32
+}
+
+type GetFeedPostsForUserRow struct {
+	ID          int32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description string
+	PublishedAt time.Time
+	FeedID      int32
+	IsRead      bool
+	IsStarred   bool
+}
+
+func (q *Queries) GetFeedPostsForUser(ctx context.Context, arg GetFeedPostsForUserParams) ([]GetFeedPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedPostsForUser, arg.UserID, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedPostsForUserRow
+	for rows.Next() {
+		var i GetFeedPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByURL = `-- name: GetPostByURL :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id
+FROM posts
+WHERE url = $1
+`
+
+func (q *Queries) GetPostByURL(ctx context.Context, url string) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByURL, url)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+	)
+	return i, err
 }
 
 const getPostsByUser = `-- name: GetPostsByUser :many
@@ -53,7 +265,9 @@ INNER JOIN feed_follows
 ON posts.feed_id = feed_follows.feed_id
 INNER JOIN users
 ON feed_follows.user_id = users.id
-WHERE users.name = $1
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = users.id
+WHERE users.name = $1 AND COALESCE(post_states.hidden, FALSE) = FALSE
 LIMIT $2
 `
 
@@ -93,3 +307,282 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 	}
 	return items, nil
 }
+
+const getPostsByUserInCategory = `-- name: GetPostsByUserInCategory :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feed_follow_categories
+ON feed_follow_categories.feed_follow_id = feed_follows.id
+INNER JOIN categories
+ON feed_follow_categories.category_id = categories.id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND categories.name = $2 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $3
+`
+
+type GetPostsByUserInCategoryParams struct {
+	UserID uuid.UUID
+	Name   string
+	Limit  int32
+}
+
+func (q *Queries) GetPostsByUserInCategory(ctx context.Context, arg GetPostsByUserInCategoryParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByUserInCategory, arg.UserID, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsByUserWithTag = `-- name: GetPostsByUserWithTag :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id
+FROM posts
+INNER JOIN post_tags
+ON post_tags.post_id = posts.id
+INNER JOIN tags
+ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1 AND tags.name = $2
+ORDER BY posts.published_at DESC
+LIMIT $3
+`
+
+type GetPostsByUserWithTagParams struct {
+	UserID uuid.UUID
+	Name   string
+	Limit  int32
+}
+
+func (q *Queries) GetPostsByUserWithTag(ctx context.Context, arg GetPostsByUserWithTagParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsByUserWithTag, arg.UserID, arg.Name, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Post
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRiverForUser = `-- name: GetRiverForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $2
+`
+
+type GetRiverForUserParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type GetRiverForUserRow struct {
+	ID          int32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description string
+	PublishedAt time.Time
+	FeedID      int32
+	FeedName    string
+	IsRead      bool
+	IsStarred   bool
+}
+
+func (q *Queries) GetRiverForUser(ctx context.Context, arg GetRiverForUserParams) ([]GetRiverForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRiverForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRiverForUserRow
+	for rows.Next() {
+		var i GetRiverForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadRiverForUser = `-- name: GetUnreadRiverForUser :many
+SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, feeds.name AS feed_name, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.hidden, FALSE) = FALSE AND COALESCE(post_states.read, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $2
+`
+
+type GetUnreadRiverForUserParams struct {
+	UserID uuid.UUID
+	Limit  int32
+}
+
+type GetUnreadRiverForUserRow struct {
+	ID          int32
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description string
+	PublishedAt time.Time
+	FeedID      int32
+	FeedName    string
+	IsRead      bool
+	IsStarred   bool
+}
+
+func (q *Queries) GetUnreadRiverForUser(ctx context.Context, arg GetUnreadRiverForUserParams) ([]GetUnreadRiverForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadRiverForUser, arg.UserID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnreadRiverForUserRow
+	for rows.Next() {
+		var i GetUnreadRiverForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const restorePost = `-- name: RestorePost :one
+INSERT INTO posts(created_at, updated_at, title, url, description, published_at, feed_id)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING id
+`
+
+type RestorePostParams struct {
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Title       string
+	Url         string
+	Description string
+	PublishedAt time.Time
+	FeedID      int32
+}
+
+func (q *Queries) RestorePost(ctx context.Context, arg RestorePostParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, restorePost,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.FeedID,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
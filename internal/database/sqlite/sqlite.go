@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"time"
+	"regexp"
+	"context"
+	"net/url"
+	"path/filepath"
+	"database/sql"
+	"encoding/json"
+	"gator/internal/database"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLite backend for single-user installs. The sqlc queries are written in a
+// dialect both engines understand, so they run unchanged through a DBTX that
+// rewrites PostgreSQL's $N placeholders to SQLite's ?N; the few queries using
+// PostgreSQL-only syntax are overridden below.
+
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+type Queries struct {
+	*database.Queries
+
+	db   database.DBTX
+	conn *sql.DB
+}
+
+// Open opens (creating if needed) the database file at path.
+func Open(path string) (*Queries, *sql.DB, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The path is escaped, a ? or # in it would otherwise end the file name,
+	// and absolute, a relative one would be taken for the URI's host.
+	dsn := url.URL{
+		Scheme:   "file",
+		Path:     path,
+		RawQuery: "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)",
+	}
+
+	conn, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// SQLite has a single writer, one connection avoids "database is locked".
+	conn.SetMaxOpenConns(1)
+
+	return New(conn), conn, nil
+}
+
+func New(conn *sql.DB) *Queries {
+	db := rebind{db: conn}
+
+	return &Queries{Queries: database.New(db), db: db, conn: conn}
+}
+
+func (q *Queries) InTx(ctx context.Context, fn func(database.Store) error) error {
+	if q.conn == nil {
+		// Already inside a transaction.
+		return fn(q)
+	}
+
+	tx, err := q.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	db := rebind{db: tx}
+	if err := fn(&Queries{Queries: database.New(db), db: db}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InSnapshot needs no isolation level, a SQLite transaction reads one snapshot
+// of the database from its first read to its end.
+func (q *Queries) InSnapshot(ctx context.Context, fn func(database.Store) error) error {
+	return q.InTx(ctx, fn)
+}
+
+const createFeedFollow = `INSERT INTO feed_follows(created_at, updated_at, user_id, feed_id)
+VALUES(?1, ?2, ?3, ?4)
+RETURNING id, created_at, updated_at, user_id, feed_id`
+
+const getFeedFollowNames = `SELECT feeds.name, users.name
+FROM feeds, users
+WHERE feeds.id = ?1 AND users.id = ?2`
+
+// CreateFeedFollow replaces the PostgreSQL data-modifying CTE with an insert
+// followed by a lookup of the names.
+func (q *Queries) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	var i database.CreateFeedFollowRow
+
+	row := q.db.QueryRowContext(ctx, createFeedFollow, arg.CreatedAt, arg.UpdatedAt, arg.UserID, arg.FeedID)
+	if err := row.Scan(&i.ID, &i.CreatedAt, &i.UpdatedAt, &i.UserID, &i.FeedID); err != nil {
+		return i, err
+	}
+
+	err := q.db.QueryRowContext(ctx, getFeedFollowNames, i.FeedID, i.UserID).Scan(&i.FeedName, &i.UserName)
+
+	return i, err
+}
+
+const getPostItemsByIDs = `SELECT posts.id, posts.created_at, posts.updated_at, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = ?1 AND posts.id IN (SELECT value FROM json_each(?2))
+ORDER BY posts.id ASC`
+
+// GetPostItemsByIDs passes the ids as a JSON array instead of a PostgreSQL
+// int[].
+func (q *Queries) GetPostItemsByIDs(ctx context.Context, arg database.GetPostItemsByIDsParams) ([]database.GetPostItemsByIDsRow, error) {
+	ids, err := json.Marshal(arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, getPostItemsByIDs, arg.UserID, string(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []database.GetPostItemsByIDsRow
+	for rows.Next() {
+		var i database.GetPostItemsByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.IsRead,
+			&i.IsStarred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+
+	return items, rows.Err()
+}
+
+// rebind adapts PostgreSQL flavoured queries and arguments to SQLite.
+type rebind struct {
+	db database.DBTX
+}
+
+func (r rebind) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.db.ExecContext(ctx, rewrite(query), convert(args)...)
+}
+
+func (r rebind) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.db.PrepareContext(ctx, rewrite(query))
+}
+
+func (r rebind) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.db.QueryContext(ctx, rewrite(query), convert(args)...)
+}
+
+func (r rebind) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.db.QueryRowContext(ctx, rewrite(query), convert(args)...)
+}
+
+func rewrite(query string) string {
+	return placeholder.ReplaceAllString(query, "?$1")
+}
+
+// convert stores every timestamp in UTC, SQLite compares them as text so
+// mixed offsets would sort wrong.
+func convert(args []interface{}) []interface{} {
+	res := make([]interface{}, len(args))
+	for i, v := range args {
+		switch t := v.(type) {
+		case time.Time:
+			res[i] = t.UTC()
+		case sql.NullTime:
+			if t.Valid {
+				res[i] = t.Time.UTC()
+			} else {
+				res[i] = nil
+			}
+		default:
+			res[i] = v
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+// Roles a user can have in users.role, admins may run commands affecting
+// every user.
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Store is everything gator needs from its database. *Queries, generated by
+// sqlc for PostgreSQL, implements it directly, internal/database/sqlite adapts
+// the same queries to SQLite.
+type Store interface {
+	CategorizeFollow(ctx context.Context, arg CategorizeFollowParams) error
+	CountAdmins(ctx context.Context) (int64, error)
+	CountFeedFollowers(ctx context.Context, feedID int32) (int64, error)
+	CountPostsForFeed(ctx context.Context, feedID int32) (int64, error)
+	CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error)
+	CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error)
+	CreateFilter(ctx context.Context, arg CreateFilterParams) (Filter, error)
+	CreatePost(ctx context.Context, arg CreatePostParams) (int64, error)
+	CreateTag(ctx context.Context, arg CreateTagParams) (Tag, error)
+	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	DeleteCategory(ctx context.Context, arg DeleteCategoryParams) (int64, error)
+	DeleteFeed(ctx context.Context, id int32) (int64, error)
+	DeleteFilter(ctx context.Context, arg DeleteFilterParams) (int64, error)
+	DeletePosts(ctx context.Context) (int64, error)
+	DeletePostsBeyondLimit(ctx context.Context, arg DeletePostsBeyondLimitParams) (int64, error)
+	DeletePostsPublishedBefore(ctx context.Context, arg DeletePostsPublishedBeforeParams) (int64, error)
+	DeleteTagIfUnused(ctx context.Context, id int32) error
+	DeleteUser(ctx context.Context, name string) (int64, error)
+	GetAllCategories(ctx context.Context) ([]Category, error)
+	GetAllFeedFollowCategories(ctx context.Context) ([]FeedFollowCategory, error)
+	GetAllFeedFollows(ctx context.Context) ([]FeedFollow, error)
+	GetAllFilters(ctx context.Context) ([]Filter, error)
+	GetAllPostContents(ctx context.Context) ([]PostContent, error)
+	GetAllPostStates(ctx context.Context) ([]PostState, error)
+	GetAllPostTags(ctx context.Context) ([]PostTag, error)
+	GetAllPosts(ctx context.Context) ([]Post, error)
+	GetAllTags(ctx context.Context) ([]Tag, error)
+	GetAllUsers(ctx context.Context) ([]User, error)
+	GetCategoriesForUser(ctx context.Context, userID uuid.UUID) ([]GetCategoriesForUserRow, error)
+	GetCategory(ctx context.Context, arg GetCategoryParams) (Category, error)
+	GetDueFeeds(ctx context.Context, nextFetchAt sql.NullTime) ([]Feed, error)
+	GetFeedByID(ctx context.Context, id int32) (Feed, error)
+	GetFeedByURL(ctx context.Context, url string) (Feed, error)
+	GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error)
+	GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error)
+	GetFeedPostsForUser(ctx context.Context, arg GetFeedPostsForUserParams) ([]GetFeedPostsForUserRow, error)
+	GetFeeds(ctx context.Context) ([]Feed, error)
+	GetFilter(ctx context.Context, arg GetFilterParams) (Filter, error)
+	GetFiltersForFeed(ctx context.Context, feedID sql.NullInt32) ([]Filter, error)
+	GetFiltersForUser(ctx context.Context, userID uuid.UUID) ([]Filter, error)
+	GetFollowCategoriesForUser(ctx context.Context, userID uuid.UUID) ([]GetFollowCategoriesForUserRow, error)
+	GetFollowedFeedsForUser(ctx context.Context, userID uuid.UUID) ([]Feed, error)
+	GetFollowedFeedsInCategory(ctx context.Context, arg GetFollowedFeedsInCategoryParams) ([]Feed, error)
+	GetNextFeedToFetch(ctx context.Context) (Feed, error)
+	GetPostByURL(ctx context.Context, url string) (Post, error)
+	GetPostContent(ctx context.Context, postID int32) (PostContent, error)
+	GetPostItemsBefore(ctx context.Context, arg GetPostItemsBeforeParams) ([]GetPostItemsBeforeRow, error)
+	GetPostItemsByIDs(ctx context.Context, arg GetPostItemsByIDsParams) ([]GetPostItemsByIDsRow, error)
+	GetPostItemsSince(ctx context.Context, arg GetPostItemsSinceParams) ([]GetPostItemsSinceRow, error)
+	GetPostsByUser(ctx context.Context, arg GetPostsByUserParams) ([]Post, error)
+	GetPostsByUserInCategory(ctx context.Context, arg GetPostsByUserInCategoryParams) ([]Post, error)
+	GetPostsByUserWithTag(ctx context.Context, arg GetPostsByUserWithTagParams) ([]Post, error)
+	GetRiverForUser(ctx context.Context, arg GetRiverForUserParams) ([]GetRiverForUserRow, error)
+	GetStarredPostIDsForUser(ctx context.Context, userID uuid.UUID) ([]int32, error)
+	GetTag(ctx context.Context, arg GetTagParams) (Tag, error)
+	GetTagsForPost(ctx context.Context, arg GetTagsForPostParams) ([]string, error)
+	GetTagsForUser(ctx context.Context, userID uuid.UUID) ([]GetTagsForUserRow, error)
+	GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]GetUnreadCountsForUserRow, error)
+	GetUnreadPostIDsForUser(ctx context.Context, userID uuid.UUID) ([]int32, error)
+	GetUnreadRiverForUser(ctx context.Context, arg GetUnreadRiverForUserParams) ([]GetUnreadRiverForUserRow, error)
+	GetUser(ctx context.Context, name string) (User, error)
+	GetUserByAPIKey(ctx context.Context, apiKey sql.NullString) (User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (User, error)
+	GetUsers(ctx context.Context) ([]string, error)
+	HidePost(ctx context.Context, arg HidePostParams) error
+	MarkAllReadBefore(ctx context.Context, arg MarkAllReadBeforeParams) error
+	MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error
+	MarkFeedReadBefore(ctx context.Context, arg MarkFeedReadBeforeParams) error
+	RenameFeed(ctx context.Context, arg RenameFeedParams) error
+	RenameUser(ctx context.Context, arg RenameUserParams) error
+	ResetUsers(ctx context.Context) error
+	RestoreFeed(ctx context.Context, arg RestoreFeedParams) (int32, error)
+	RestorePost(ctx context.Context, arg RestorePostParams) (int32, error)
+	RestorePostState(ctx context.Context, arg RestorePostStateParams) error
+	RestoreUser(ctx context.Context, arg RestoreUserParams) error
+	SetFeedFullText(ctx context.Context, arg SetFeedFullTextParams) error
+	SetFeedMetadata(ctx context.Context, arg SetFeedMetadataParams) error
+	SetFeedNextFetch(ctx context.Context, arg SetFeedNextFetchParams) error
+	SetFeedRetention(ctx context.Context, arg SetFeedRetentionParams) error
+	SetFeedURL(ctx context.Context, arg SetFeedURLParams) error
+	SetPostContent(ctx context.Context, arg SetPostContentParams) error
+	SetPostRead(ctx context.Context, arg SetPostReadParams) error
+	SetPostStarred(ctx context.Context, arg SetPostStarredParams) error
+	SetUserAPIKey(ctx context.Context, arg SetUserAPIKeyParams) error
+	SetUserRole(ctx context.Context, arg SetUserRoleParams) error
+	TagPost(ctx context.Context, arg TagPostParams) error
+	UncategorizeFollow(ctx context.Context, arg UncategorizeFollowParams) (int64, error)
+	UnfollowFeed(ctx context.Context, arg UnfollowFeedParams) error
+	UntagPost(ctx context.Context, arg UntagPostParams) (int64, error)
+
+	// InTx runs fn against a Store bound to one transaction, committed when
+	// fn returns nil and rolled back otherwise.
+	InTx(ctx context.Context, fn func(Store) error) error
+	// InSnapshot is InTx for reads that have to see the database at a single
+	// moment, like a backup, while other commands keep writing.
+	InSnapshot(ctx context.Context, fn func(Store) error) error
+}
+
+func (q *Queries) InTx(ctx context.Context, fn func(Store) error) error {
+	return q.inTx(ctx, nil, fn)
+}
+
+func (q *Queries) InSnapshot(ctx context.Context, fn func(Store) error) error {
+	// REPEATABLE READ sees what was committed before the first query, for
+	// every query of the transaction.
+	return q.inTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func (q *Queries) inTx(ctx context.Context, opts *sql.TxOptions, fn func(Store) error) error {
+	conn, ok := q.db.(*sql.DB)
+	if !ok {
+		// Already inside a transaction.
+		return fn(q)
+	}
+
+	tx, err := conn.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(q.WithTx(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
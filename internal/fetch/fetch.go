@@ -0,0 +1,255 @@
+package fetch
+
+import (
+	"io"
+	"net"
+	"fmt"
+	"time"
+	"context"
+	"strings"
+	"net/url"
+	"net/http"
+	"compress/gzip"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Every download of gator, feeds, pages searched for a feed and articles,
+// goes through a Fetcher, so no endpoint can hang a run or fill the memory.
+
+// Version is reported in the User-Agent, release builds set it with
+// -ldflags "-X gator/internal/fetch.Version=1.2.3".
+var Version = "dev"
+
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReadTimeout    = 30 * time.Second
+	DefaultMaxBodySize    = 10 << 20
+	// DefaultContactURL tells the owners of a site who is fetching it.
+	DefaultContactURL = "https://github.com/Diwice/gator"
+)
+
+// Options configures a Fetcher, zero values take the defaults.
+type Options struct {
+	// ConnectTimeout bounds the dial and the TLS handshake, ReadTimeout
+	// everything after, from sending the request to the end of the body.
+	ConnectTimeout time.Duration
+	ReadTimeout    time.Duration
+	// MaxBodySize caps the body in bytes, after decompression.
+	MaxBodySize int64
+	// Proxy is an http, https or socks5 URL, the environment's
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY are used when it is empty.
+	Proxy      string
+	ContactURL string
+	// Guard refuses to connect to loopback, link-local, private and
+	// metadata addresses, but those of AllowedHosts, each a host name, an
+	// address or a CIDR range.
+	Guard        bool
+	AllowedHosts []string
+}
+
+// maxRedirects is as many redirects as net/http follows by default.
+const maxRedirects = 10
+
+type Fetcher struct {
+	client     *http.Client
+	user_agent string
+	max_body   int64
+
+	// guard is nil when not guarding, proxy tells which requests go through
+	// a proxy, whose target hosts the dialer never sees.
+	guard *guard
+	proxy func(*http.Request) (*url.URL, error)
+}
+
+// Response is a fully read response.
+type Response struct {
+	// URL is where the body came from after redirects.
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+func New(opts Options) (*Fetcher, error) {
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+
+	if opts.ContactURL == "" {
+		opts.ContactURL = DefaultContactURL
+	}
+
+	res := &Fetcher{
+		user_agent: fmt.Sprintf("gator/%s (+%s)", Version, opts.ContactURL),
+		max_body:   opts.MaxBodySize,
+		proxy:      http.ProxyFromEnvironment,
+	}
+
+	var proxy_url *url.URL
+	if opts.Proxy != "" {
+		var err error
+		if proxy_url, err = ParseProxy(opts.Proxy); err != nil {
+			return nil, err
+		}
+
+		res.proxy = http.ProxyURL(proxy_url)
+	}
+
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout, KeepAlive: 30 * time.Second}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = res.proxy
+	transport.DialContext = dialer.DialContext
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+	// Accept-Encoding is set by Get, which decodes gzip and brotli itself.
+	transport.DisableCompression = true
+
+	if opts.Guard {
+		g, err := newGuard(opts.AllowedHosts)
+		if err != nil {
+			return nil, err
+		}
+
+		// The configured proxy is trusted wherever it is.
+		if proxy_url != nil {
+			g.hosts[strings.ToLower(proxy_url.Hostname())] = true
+		}
+
+		res.guard = g
+		transport.DialContext = g.dialContext(dialer)
+	}
+
+	res.client = &http.Client{
+		Transport:     transport,
+		Timeout:       opts.ConnectTimeout + opts.ReadTimeout,
+		CheckRedirect: res.checkRedirect,
+	}
+
+	return res, nil
+}
+
+// ParseProxy checks a proxy URL of the config, errors name the setting.
+func ParseProxy(proxy string) (*url.URL, error) {
+	proxy_url, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("proxy is not a URL - %s", proxy)
+	}
+
+	switch proxy_url.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("proxy must be an http, https or socks5 URL - %s", proxy)
+	}
+
+	if proxy_url.Host == "" {
+		return nil, fmt.Errorf("proxy has no host - %s", proxy)
+	}
+
+	return proxy_url, nil
+}
+
+// checkRequest refuses schemes other than http and https and, behind a proxy,
+// hosts the guard blocks.
+func (f *Fetcher) checkRequest(req *http.Request) error {
+	if err := CheckURL(req.URL.String()); err != nil {
+		return err
+	}
+
+	if f.guard == nil {
+		return nil
+	}
+
+	proxy_url, err := f.proxy(req)
+	if err != nil || proxy_url == nil {
+		// Without a proxy the dialer checks every address.
+		return err
+	}
+
+	return f.guard.checkHost(req.Context(), req.URL.Hostname())
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("Stopped after %d redirects", maxRedirects)
+	}
+
+	return f.checkRequest(req)
+}
+
+// Get downloads target, accept lists the media types wanted. Responses
+// outside 200-299, with an unknown encoding or a body over the size limit
+// are errors.
+func (f *Fetcher) Get(ctx context.Context, target, accept string) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.checkRequest(req); err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", f.user_agent)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 299 {
+		return nil, fmt.Errorf("Response Status Code was not 200-: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength > f.max_body {
+		return nil, fmt.Errorf("Response of %s is %d bytes, over the limit of %d", target, resp.ContentLength, f.max_body)
+	}
+
+	var body io.Reader = resp.Body
+
+	switch encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		body = gz
+	case "br":
+		body = brotli.NewReader(resp.Body)
+	default:
+		return nil, fmt.Errorf("Unsupported Content-Encoding - %s", encoding)
+	}
+
+	// One byte over the limit is enough to know the body is too large.
+	data, err := io.ReadAll(io.LimitReader(body, f.max_body+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > f.max_body {
+		return nil, fmt.Errorf("Response of %s is over the limit of %d bytes", target, f.max_body)
+	}
+
+	// The body is decoded, the headers describing the encoded one no longer
+	// apply.
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+
+	return &Response{URL: resp.Request.URL, Header: resp.Header, Body: data}, nil
+}
@@ -0,0 +1,191 @@
+package fetch
+
+import (
+	"net"
+	"fmt"
+	"errors"
+	"context"
+	"strings"
+	"syscall"
+	"net/url"
+	"net/netip"
+)
+
+// The guard keeps users of a shared gator from making it request internal
+// services, like the cloud metadata endpoint at 169.254.169.254. Addresses
+// are checked when they are dialed, after DNS resolution and on every
+// redirect, so neither a hostname pointing inside nor a redirect gets
+// through.
+
+// blockedRanges are not covered by the checks of netip.Addr.
+var blockedRanges = []netip.Prefix{
+	// "This network", carrier-grade NAT, IETF protocol assignments and
+	// benchmarking.
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	// NAT64, which would reach any of the above.
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// ErrBlocked is returned for requests the guard refuses.
+var ErrBlocked = errors.New("address is private or internal")
+
+// blockedAddr reports whether ip is loopback, link-local, private, metadata
+// or otherwise not a public address.
+func blockedAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+
+	for _, v := range blockedRanges {
+		if v.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type guard struct {
+	// hosts are allowed by name, prefixes by address.
+	hosts    map[string]bool
+	prefixes []netip.Prefix
+}
+
+// newGuard reads the allowlist, every entry a host name, an address or a
+// CIDR range.
+func newGuard(allow []string) (*guard, error) {
+	g := &guard{hosts: make(map[string]bool)}
+
+	for _, v := range allow {
+		if err := ParseAllowed(v); err != nil {
+			return nil, err
+		}
+
+		v = strings.ToLower(strings.TrimSpace(v))
+
+		if prefix, err := netip.ParsePrefix(v); err == nil {
+			g.prefixes = append(g.prefixes, prefix.Masked())
+		} else if ip, err := netip.ParseAddr(v); err == nil {
+			g.prefixes = append(g.prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+		} else {
+			g.hosts[v] = true
+		}
+	}
+
+	return g, nil
+}
+
+// ParseAllowed checks an allowed_hosts entry of the config, errors name the
+// setting.
+func ParseAllowed(entry string) error {
+	entry = strings.TrimSpace(entry)
+
+	if entry == "" || (strings.ContainsAny(entry, " :/") && !isAddr(entry)) {
+		return fmt.Errorf("allowed_hosts entries must be host names, addresses or CIDR ranges - %q", entry)
+	}
+
+	return nil
+}
+
+func isAddr(entry string) bool {
+	if _, err := netip.ParsePrefix(entry); err == nil {
+		return true
+	}
+
+	_, err := netip.ParseAddr(entry)
+	return err == nil
+}
+
+func (g *guard) allowedHost(host string) bool {
+	return g.hosts[strings.ToLower(strings.TrimSuffix(host, "."))]
+}
+
+func (g *guard) allowedAddr(ip netip.Addr) bool {
+	if !blockedAddr(ip) {
+		return true
+	}
+
+	for _, v := range g.prefixes {
+		if v.Contains(ip.Unmap()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dialContext wraps dialer so it refuses blocked addresses, unless the host
+// dialed is allowed by name.
+func (g *guard) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	guarded := *dialer
+	// Control runs on the resolved address right before connecting, for
+	// every address the dialer tries.
+	guarded.Control = func(network, address string, _ syscall.RawConn) error {
+		addr_port, err := netip.ParseAddrPort(address)
+		if err != nil {
+			return err
+		}
+
+		if !g.allowedAddr(addr_port.Addr()) {
+			return fmt.Errorf("Refusing to connect to %s - %w", addr_port.Addr().Unmap(), ErrBlocked)
+		}
+
+		return nil
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err == nil && g.allowedHost(host) {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		return guarded.DialContext(ctx, network, addr)
+	}
+}
+
+// checkHost resolves host and refuses it when any of its addresses is
+// blocked. Only used behind a proxy, which connects on its own.
+func (g *guard) checkHost(ctx context.Context, host string) error {
+	if g.allowedHost(host) {
+		return nil
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range ips {
+		if !g.allowedAddr(v) {
+			return fmt.Errorf("Refusing to fetch %s at %s - %w", host, v.Unmap(), ErrBlocked)
+		}
+	}
+
+	return nil
+}
+
+// CheckURL refuses URLs gator can't fetch, anything but http and https.
+func CheckURL(raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("Invalid URL %s - %w", raw, err)
+	}
+
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return fmt.Errorf("Expected an http or https URL, got - %s", raw)
+	}
+
+	if target.Host == "" {
+		return fmt.Errorf("Expected a URL with a host, got - %s", raw)
+	}
+
+	return nil
+}
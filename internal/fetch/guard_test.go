@@ -0,0 +1,189 @@
+package fetch
+
+import (
+	"bytes"
+	"errors"
+	"context"
+	"strings"
+	"testing"
+	"net/url"
+	"net/http"
+	"net/netip"
+	"compress/gzip"
+	"net/http/httptest"
+)
+
+func TestBlockedAddr(t *testing.T) {
+	tests := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"127.1.2.3", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"::ffff:169.254.169.254", true},
+		{"fe80::1", true},
+		{"10.0.0.1", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"fd00::1", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"93.184.216.34", false},
+		{"::ffff:93.184.216.34", false},
+		{"2606:4700::1111", false},
+	}
+
+	for _, v := range tests {
+		if got := blockedAddr(netip.MustParseAddr(v.addr)); got != v.blocked {
+			t.Errorf("blockedAddr(%s) = %v, want %v", v.addr, got, v.blocked)
+		}
+	}
+}
+
+// hostURL is the URL of srv with its address replaced by host.
+func hostURL(t *testing.T, srv *httptest.Server, host string) string {
+	t.Helper()
+
+	srv_url, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv_url.Host = host + ":" + srv_url.Port()
+
+	return srv_url.String()
+}
+
+func TestGuard(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		allow   []string
+		host    string
+		blocked bool
+	}{
+		{"loopback", nil, "127.0.0.1", true},
+		{"loopback by name", nil, "localhost", true},
+		{"loopback allowed by address", []string{"127.0.0.1"}, "127.0.0.1", false},
+		{"loopback allowed by range", []string{"127.0.0.0/8"}, "127.0.0.1", false},
+		{"loopback allowed by name", []string{"localhost"}, "localhost", false},
+		{"other address allowed", []string{"10.0.0.1"}, "127.0.0.1", true},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			fetcher, err := New(Options{Guard: true, AllowedHosts: v.allow})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = fetcher.Get(context.Background(), hostURL(t, srv, v.host), "")
+			if v.blocked && !errors.Is(err, ErrBlocked) {
+				t.Errorf("Get = %v, want %v", err, ErrBlocked)
+			}
+
+			if !v.blocked && err != nil {
+				t.Errorf("Get = %v, want no error", err)
+			}
+		})
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("internal"))
+	}))
+	defer target.Close()
+
+	tests := []struct {
+		name     string
+		location string
+		want     string
+	}{
+		{"to loopback", hostURL(t, target, "127.0.0.1"), ErrBlocked.Error()},
+		{"to IPv4-mapped loopback", hostURL(t, target, "[::ffff:127.0.0.1]"), ErrBlocked.Error()},
+		{"to a file", "file:///etc/passwd", "Expected an http or https URL"},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				http.Redirect(rw, r, v.location, http.StatusFound)
+			}))
+			defer srv.Close()
+
+			// The redirecting server is allowed by name, only the target is
+			// checked by address.
+			fetcher, err := New(Options{Guard: true, AllowedHosts: []string{"localhost"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = fetcher.Get(context.Background(), hostURL(t, srv, "localhost"), "")
+			if err == nil || !strings.Contains(err.Error(), v.want) {
+				t.Errorf("Get = %v, want an error containing %q", err, v.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	const limit = 64 << 10
+
+	var bomb bytes.Buffer
+	gz := gzip.NewWriter(&bomb)
+	gz.Write(make([]byte, 100*limit))
+	gz.Close()
+
+	if bomb.Len() >= limit {
+		t.Fatalf("The gzip bomb is %d bytes compressed, not under the limit of %d", bomb.Len(), limit)
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		ok       bool
+	}{
+		{"under the limit", "", make([]byte, limit), true},
+		{"over the limit", "", make([]byte, limit+1), false},
+		{"gzip bomb", "gzip", bomb.Bytes(), false},
+	}
+
+	for _, v := range tests {
+		t.Run(v.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				if v.encoding != "" {
+					rw.Header().Set("Content-Encoding", v.encoding)
+				}
+				rw.Write(v.body)
+			}))
+			defer srv.Close()
+
+			fetcher, err := New(Options{MaxBodySize: limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := fetcher.Get(context.Background(), srv.URL, "")
+			switch {
+			case v.ok && err != nil:
+				t.Errorf("Get = %v, want no error", err)
+			case v.ok && len(resp.Body) != len(v.body):
+				t.Errorf("Get read %d bytes, want %d", len(resp.Body), len(v.body))
+			case !v.ok && (err == nil || !strings.Contains(err.Error(), "over the limit")):
+				t.Errorf("Get = %v, want an error over the limit", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,409 @@
+package fever
+
+import (
+	"fmt"
+	"log"
+	"time"
+	"errors"
+	"strconv"
+	"strings"
+	"context"
+	"net/http"
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+)
+
+// Fever API (https://feedafever.com/api) on top of the gator tables, so native
+// readers like Reeder or NetNewsWire can sync against a self-hosted database.
+
+const (
+	apiVersion   = 3
+	itemsPerPage = 50
+	allGroupID   = 1
+)
+
+type Server struct {
+	DB database.Store
+}
+
+type group struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type feedsGroup struct {
+	GroupID int    `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feed struct {
+	ID                int32  `json:"id"`
+	FaviconID         int32  `json:"favicon_id"`
+	Title             string `json:"title"`
+	Url               string `json:"url"`
+	SiteUrl           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type item struct {
+	ID            int32  `json:"id"`
+	FeedID        int32  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	Html          string `json:"html"`
+	Url           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// APIKey returns the key a Fever client derives from the login it is given,
+// md5("<email>:<password>"). Gator has no emails, so the user name is used.
+func APIKey(username, password string) string {
+	sum := md5.Sum([]byte(username + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, ok := r.Form["api"]; !ok {
+		http.Error(w, "Expected api parameter", http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]any{
+		"api_version": apiVersion,
+		"auth":        0,
+	}
+
+	ctx := r.Context()
+
+	key := strings.ToLower(r.PostFormValue("api_key"))
+	user, err := f.DB.GetUserByAPIKey(ctx, sql.NullString{String: key, Valid: key != ""})
+	if err != nil {
+		writeJSON(w, resp)
+		return
+	}
+
+	resp["auth"] = 1
+
+	if err := f.handle(ctx, r, user, resp); err != nil {
+		log.Println("Fever request failed -", err)
+		http.Error(w, "Internal error", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, resp)
+}
+
+func (f *Server) handle(ctx context.Context, r *http.Request, user database.User, resp map[string]any) error {
+	if r.PostFormValue("mark") != "" {
+		if err := f.mark(ctx, r, user); err != nil {
+			return err
+		}
+	}
+
+	feeds, err := f.DB.GetFollowedFeedsForUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	var last_refreshed int64
+	for _, v := range feeds {
+		if v.LastFetchedAt.Valid && v.LastFetchedAt.Time.Unix() > last_refreshed {
+			last_refreshed = v.LastFetchedAt.Time.Unix()
+		}
+	}
+	resp["last_refreshed_on_time"] = last_refreshed
+
+	if has(r, "groups") || has(r, "feeds") {
+		resp["feeds_groups"] = feedsGroups(feeds)
+	}
+
+	if has(r, "groups") {
+		resp["groups"] = []group{{ID: allGroupID, Title: "All"}}
+	}
+
+	if has(r, "feeds") {
+		res := make([]feed, 0, len(feeds))
+		for _, v := range feeds {
+			res = append(res, toFeed(v))
+		}
+		resp["feeds"] = res
+	}
+
+	if has(r, "favicons") {
+		resp["favicons"] = []any{}
+	}
+
+	if has(r, "links") {
+		resp["links"] = []any{}
+	}
+
+	if has(r, "items") {
+		items, err := f.items(ctx, r, user)
+		if err != nil {
+			return err
+		}
+
+		total, err := f.DB.CountPostsForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		resp["items"] = items
+		resp["total_items"] = total
+	}
+
+	if has(r, "unread_item_ids") {
+		ids, err := f.DB.GetUnreadPostIDsForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp["unread_item_ids"] = joinIDs(ids)
+	}
+
+	if has(r, "saved_item_ids") {
+		ids, err := f.DB.GetStarredPostIDsForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		resp["saved_item_ids"] = joinIDs(ids)
+	}
+
+	return nil
+}
+
+func (f *Server) items(ctx context.Context, r *http.Request, user database.User) ([]item, error) {
+	res := []item{}
+
+	if with_ids := r.FormValue("with_ids"); with_ids != "" {
+		ids, err := splitIDs(with_ids)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ids) > itemsPerPage {
+			ids = ids[:itemsPerPage]
+		}
+
+		rows, err := f.DB.GetPostItemsByIDs(ctx, database.GetPostItemsByIDsParams{UserID: user.ID, Ids: ids})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range rows {
+			res = append(res, toItem(database.GetPostItemsSinceRow(v)))
+		}
+
+		return res, nil
+	}
+
+	if max_id := r.FormValue("max_id"); max_id != "" {
+		id, err := strconv.ParseInt(max_id, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		rows, err := f.DB.GetPostItemsBefore(ctx, database.GetPostItemsBeforeParams{UserID: user.ID, ID: int32(id), Limit: itemsPerPage})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range rows {
+			res = append(res, toItem(database.GetPostItemsSinceRow(v)))
+		}
+
+		return res, nil
+	}
+
+	var since int64
+	if since_id := r.FormValue("since_id"); since_id != "" {
+		id, err := strconv.ParseInt(since_id, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		since = id
+	}
+
+	rows, err := f.DB.GetPostItemsSince(ctx, database.GetPostItemsSinceParams{UserID: user.ID, ID: int32(since), Limit: itemsPerPage})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range rows {
+		res = append(res, toItem(v))
+	}
+
+	return res, nil
+}
+
+func (f *Server) mark(ctx context.Context, r *http.Request, user database.User) error {
+	c_time := time.Now()
+
+	id, err := strconv.ParseInt(r.PostFormValue("id"), 10, 32)
+	if err != nil {
+		return fmt.Errorf("Invalid id for mark - %w", err)
+	}
+
+	// Ids of posts and feeds the user doesn't follow are ignored like ids that
+	// don't exist, so nobody can change the state of posts they can't read.
+	switch r.PostFormValue("mark") {
+	case "item":
+		rows, err := f.DB.GetPostItemsByIDs(ctx, database.GetPostItemsByIDsParams{UserID: user.ID, Ids: []int32{int32(id)}})
+		if err != nil || len(rows) == 0 {
+			return err
+		}
+
+		switch r.PostFormValue("as") {
+		case "read", "unread":
+			return f.DB.SetPostRead(ctx, database.SetPostReadParams{
+				UserID:    user.ID,
+				PostID:    int32(id),
+				Read:      r.PostFormValue("as") == "read",
+				UpdatedAt: c_time,
+			})
+		case "saved", "unsaved":
+			return f.DB.SetPostStarred(ctx, database.SetPostStarredParams{
+				UserID:    user.ID,
+				PostID:    int32(id),
+				Starred:   r.PostFormValue("as") == "saved",
+				UpdatedAt: c_time,
+			})
+		}
+	case "feed", "group":
+		if r.PostFormValue("as") != "read" {
+			return nil
+		}
+
+		before := c_time
+		if b, err := strconv.ParseInt(r.PostFormValue("before"), 10, 64); err == nil && b > 0 {
+			before = time.Unix(b, 0)
+		}
+
+		if r.PostFormValue("mark") == "feed" {
+			_, err := f.DB.GetFeedFollow(ctx, database.GetFeedFollowParams{UserID: user.ID, FeedID: int32(id)})
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			return f.DB.MarkFeedReadBefore(ctx, database.MarkFeedReadBeforeParams{
+				UserID:    user.ID,
+				UpdatedAt: c_time,
+				FeedID:    int32(id),
+				CreatedAt: before,
+			})
+		}
+
+		// Group 0 is Fever's "Kindling" super group, everything else maps to
+		// the single "All" group, so both mean every followed feed.
+		return f.DB.MarkAllReadBefore(ctx, database.MarkAllReadBeforeParams{
+			UserID:    user.ID,
+			UpdatedAt: c_time,
+			CreatedAt: before,
+		})
+	}
+
+	return nil
+}
+
+func has(r *http.Request, key string) bool {
+	_, ok := r.Form[key]
+	return ok
+}
+
+func feedsGroups(feeds []database.Feed) []feedsGroup {
+	ids := make([]int32, 0, len(feeds))
+	for _, v := range feeds {
+		ids = append(ids, v.ID)
+	}
+
+	return []feedsGroup{{GroupID: allGroupID, FeedIDs: joinIDs(ids)}}
+}
+
+func toFeed(v database.Feed) feed {
+	var last_updated int64
+	if v.LastFetchedAt.Valid {
+		last_updated = v.LastFetchedAt.Time.Unix()
+	}
+
+	site_url := v.SiteLink
+	if site_url == "" {
+		site_url = v.Url
+	}
+
+	return feed{
+		ID:                v.ID,
+		Title:             v.Name,
+		Url:               v.Url,
+		SiteUrl:           site_url,
+		LastUpdatedOnTime: last_updated,
+	}
+}
+
+func toItem(v database.GetPostItemsSinceRow) item {
+	return item{
+		ID:            v.ID,
+		FeedID:        v.FeedID,
+		Title:         v.Title,
+		Html:          sanitize.HTML(v.Description, v.Url),
+		Url:           v.Url,
+		IsSaved:       boolInt(v.IsStarred),
+		IsRead:        boolInt(v.IsRead),
+		CreatedOnTime: v.PublishedAt.Unix(),
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func joinIDs(ids []int32) string {
+	res := make([]string, 0, len(ids))
+	for _, v := range ids {
+		res = append(res, strconv.Itoa(int(v)))
+	}
+
+	return strings.Join(res, ",")
+}
+
+func splitIDs(s string) ([]int32, error) {
+	var res []int32
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		id, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, int32(id))
+	}
+
+	return res, nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error trying to write a response -", err)
+	}
+}
@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"gator/internal/database"
+)
+
+// Filter rules match new posts of a feed by a regular expression on their
+// title, content or both, and act on them for the user owning the rule.
+
+// Fields a rule can match, as stored in filters.field.
+const (
+	FieldTitle   = "title"
+	FieldContent = "content"
+	FieldAny     = "any"
+)
+
+// Actions a rule can take, as stored in filters.action. Tagging is stored as
+// "tag:<name>".
+const (
+	ActionHide     = "hide"
+	ActionMarkRead = "markread"
+	ActionStar     = "star"
+	ActionTag      = "tag"
+)
+
+type Action struct {
+	Kind string
+	// Tag is the tag to add when Kind is ActionTag.
+	Tag string
+}
+
+func (a Action) String() string {
+	if a.Kind == ActionTag {
+		return ActionTag + ":" + a.Tag
+	}
+
+	return a.Kind
+}
+
+func ParseAction(s string) (Action, error) {
+	switch s {
+	case ActionHide, ActionMarkRead, ActionStar:
+		return Action{Kind: s}, nil
+	}
+
+	if tag, ok := strings.CutPrefix(s, ActionTag+":"); ok {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag != "" {
+			return Action{Kind: ActionTag, Tag: tag}, nil
+		}
+	}
+
+	return Action{}, fmt.Errorf("Expected action hide, markread, star or tag:<name>, got - %s", s)
+}
+
+// Keyword returns the pattern matching word anywhere, ignoring case.
+func Keyword(word string) string {
+	return "(?i)" + regexp.QuoteMeta(word)
+}
+
+type Rule struct {
+	Filter  database.Filter
+	Pattern *regexp.Regexp
+	Action  Action
+}
+
+// Compile checks a stored filter and prepares it for matching.
+func Compile(f database.Filter) (Rule, error) {
+	switch f.Field {
+	case FieldTitle, FieldContent, FieldAny:
+	default:
+		return Rule{}, fmt.Errorf("Unknown filter field - %s", f.Field)
+	}
+
+	pattern, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("Invalid filter pattern %s - %w", f.Pattern, err)
+	}
+
+	action, err := ParseAction(f.Action)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{Filter: f, Pattern: pattern, Action: action}, nil
+}
+
+// Match reports whether a post with this title and content (its description)
+// is caught by the rule.
+func (r Rule) Match(title, content string) bool {
+	switch r.Filter.Field {
+	case FieldTitle:
+		return r.Pattern.MatchString(title)
+	case FieldContent:
+		return r.Pattern.MatchString(content)
+	default:
+		return r.Pattern.MatchString(title) || r.Pattern.MatchString(content)
+	}
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"io"
+	"os"
+	"fmt"
+	"time"
+	"context"
+	"strings"
+	"syscall"
+	"os/signal"
+	"database/sql"
+	"gator/internal/rss"
+	"gator/internal/state"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+)
+
+type aggSummary struct {
+	runs     int
+	fetched  int
+	failed   int
+	newPosts int64
+	pruned   int64
+}
+
+const (
+	defaultMinFetch = 15 * time.Minute
+	defaultMaxFetch = 24 * time.Hour
+
+	// pollInterval caps how long agg sleeps, so feeds added meanwhile are
+	// picked up without a restart.
+	pollInterval = time.Minute
+
+	pruneInterval = time.Hour
+)
+
+// fetchSchedule returns the per-feed refresh bounds from the config, falling
+// back to the defaults for unset or invalid values.
+func fetchSchedule(s *state.State) rss.Schedule {
+	sched := rss.Schedule{Min: defaultMinFetch, Max: defaultMaxFetch}
+
+	if d, err := time.ParseDuration(s.Cfg.Min_Fetch_Interval); err == nil && d > 0 {
+		sched.Min = d
+	}
+
+	if d, err := time.ParseDuration(s.Cfg.Max_Fetch_Interval); err == nil && d > 0 {
+		sched.Max = d
+	}
+
+	if sched.Max < sched.Min {
+		sched.Max = sched.Min
+	}
+
+	return sched
+}
+
+// scrapeDue refreshes every feed whose next_fetch_at has passed.
+func scrapeDue(ctx context.Context, s *state.State, sched rss.Schedule, summary *aggSummary) error {
+	summary.runs++
+
+	feeds, err := s.DB.GetDueFeeds(ctx, sql.NullTime{Time: time.Now(), Valid: true})
+	if err != nil {
+		return err
+	}
+
+	for _, feed := range feeds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		created, err := scrapeFeed(ctx, s, feed, sched, os.Stdout)
+		if err != nil {
+			summary.failed++
+			fmt.Printf("FAILED - %s (URL:%s) ; %v\n", feed.Name, feed.Url, err)
+			continue
+		}
+
+		summary.fetched++
+		summary.newPosts += created
+	}
+
+	return nil
+}
+
+// nextWait returns how long to sleep until the earliest scheduled feed.
+func nextWait(ctx context.Context, s *state.State) time.Duration {
+	feed, err := s.DB.GetNextFeedToFetch(ctx)
+	if err != nil || !feed.NextFetchAt.Valid {
+		return pollInterval
+	}
+
+	wait := time.Until(feed.NextFetchAt.Time)
+	if wait < time.Second {
+		wait = time.Second
+	}
+
+	return min(wait, pollInterval)
+}
+
+// scrapeFeed fetches one feed and stores its new posts in a single transaction,
+// so a cancelled ctx either lets the writes finish or rolls all of them back.
+// Progress is reported on out.
+func scrapeFeed(ctx context.Context, s *state.State, feed database.Feed, sched rss.Schedule, out io.Writer) (int64, error) {
+	fmt.Fprintf(out, "Fetching the feed - %s\n", feed.Name)
+
+	c_time := time.Now()
+
+	// Until the fetch succeeds the feed is retried after a backoff, so one
+	// broken feed cannot hold up the rest.
+	feed_params := database.MarkFeedFetchedParams{
+		ID:          feed.ID,
+		UpdatedAt:   c_time,
+		NextFetchAt: sql.NullTime{Time: c_time.Add(min(4*sched.Min, sched.Max)), Valid: true},
+	}
+
+	if err := s.DB.MarkFeedFetched(ctx, feed_params); err != nil {
+		return 0, err
+	}
+
+	res, err := rss.FetchFeed(ctx, s.Fetcher, feed.Url)
+	if err != nil {
+		return 0, err
+	}
+
+	var posts []database.CreatePostParams
+	for _, v := range res.Channel.Item {
+		s_time := time.Now()
+
+		p_time, err := rss.ParsePubDate(v.PubDate)
+		if err != nil {
+			fmt.Fprintln(out, "Error trying to parse time of a post -", err)
+			continue
+		}
+
+		posts = append(posts, database.CreatePostParams{
+			CreatedAt:   s_time,
+			UpdatedAt:   s_time,
+			Title:       v.Title,
+			Url:         v.Link,
+			Description: v.Description,
+			PublishedAt: p_time,
+			FeedID:      feed.ID,
+		})
+	}
+
+	// Posts the retention policy would prune right away are not stored, or
+	// every fetch would bring them back.
+	posts = retainedPosts(retentionFor(s, feed), posts)
+
+	rules, err := feedRules(ctx, s, feed, out)
+	if err != nil {
+		return 0, err
+	}
+
+	var created int64
+	var filtered int
+	// full_text collects the new posts whose articles are fetched once they
+	// are stored.
+	var full_text []database.Post
+	next_params := database.SetFeedNextFetchParams{
+		ID:          feed.ID,
+		NextFetchAt: sql.NullTime{Time: res.NextFetch(time.Now(), sched), Valid: true},
+	}
+
+	meta_params := database.SetFeedMetadataParams{
+		ID:            feed.ID,
+		Title:         strings.TrimSpace(res.Channel.Title),
+		SiteLink:      strings.TrimSpace(res.Channel.Link),
+		Description:   strings.TrimSpace(res.Channel.Description),
+		Language:      strings.TrimSpace(res.Channel.Language),
+		ImageUrl:      res.SiteImage(),
+		Generator:     strings.TrimSpace(res.Channel.Generator),
+		ParseWarnings: strings.Join(res.Warnings, "\n"),
+	}
+
+	err = s.DB.InTx(ctx, func(qtx database.Store) error {
+		if err := qtx.SetFeedMetadata(ctx, meta_params); err != nil {
+			return err
+		}
+
+		for _, post_params := range posts {
+			n, err := qtx.CreatePost(ctx, post_params)
+			if err != nil {
+				return err
+			}
+
+			created += n
+
+			// Filters and full text only act on posts seen for the first time.
+			if n == 0 || (len(rules) == 0 && !feed.FullText) {
+				continue
+			}
+
+			post, err := qtx.GetPostByURL(ctx, post_params.Url)
+			if err != nil {
+				return err
+			}
+
+			if feed.FullText {
+				full_text = append(full_text, post)
+			}
+
+			matched, err := applyRules(ctx, qtx, rules, post)
+			if err != nil {
+				return err
+			}
+			filtered += matched
+		}
+
+		return qtx.SetFeedNextFetch(ctx, next_params)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Fprintf(out, "Stored %d new posts from - %s ; next fetch at %s\n", created, feed.Name, next_params.NextFetchAt.Time.Local().Format(time.DateTime))
+
+	for _, v := range res.Warnings {
+		fmt.Fprintf(out, "Warning for feed - %s ; %s\n", feed.Name, sanitize.StripControl(v))
+	}
+
+	if filtered > 0 {
+		fmt.Fprintf(out, "Applied %d filter matches to new posts from - %s\n", filtered, feed.Name)
+	}
+
+	// Articles are fetched outside the transaction, a slow or broken page
+	// loses its full text, not the posts.
+	fetchArticles(ctx, s, feed, full_text, out)
+
+	return created, nil
+}
+
+func handlerAgg(s *state.State, cmd Command) error {
+	var once, prune bool
+	var feed_url string
+
+	sched := fetchSchedule(s)
+
+	for i := 0; i < len(cmd.args); i++ {
+		switch cmd.args[i] {
+		case "--once":
+			once = true
+		case "--prune":
+			prune = true
+		case "--feed":
+			if i+1 >= len(cmd.args) {
+				return fmt.Errorf("Expected URL after --feed")
+			}
+
+			i++
+			feed_url = clean_input(cmd.args[i])
+		default:
+			c_time, err := time.ParseDuration(cmd.args[i])
+			if err != nil {
+				return err
+			}
+
+			sched.Min = c_time
+			sched.Max = max(sched.Max, c_time)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if feed_url != "" {
+		return aggFeed(ctx, s, feed_url, sched)
+	}
+
+	if once {
+		if err := aggOnce(ctx, s, sched); err != nil {
+			return err
+		}
+
+		if prune {
+			_, err := pruneFeeds(ctx, s, false)
+			return err
+		}
+
+		return nil
+	}
+
+	fmt.Printf("Collecting feeds on their own schedule, every %v to %v\n", sched.Min, sched.Max)
+
+	start := time.Now()
+	summary := aggSummary{}
+
+	var last_prune time.Time
+
+	for {
+		if err := scrapeDue(ctx, s, sched, &summary); err != nil && ctx.Err() == nil {
+			fmt.Println("Error trying to scrape feeds -", err)
+		}
+
+		if prune && time.Since(last_prune) >= pruneInterval && ctx.Err() == nil {
+			last_prune = time.Now()
+
+			removed, err := pruneFeeds(ctx, s, false)
+			if err != nil && ctx.Err() == nil {
+				fmt.Println("Error trying to prune posts -", err)
+			}
+			summary.pruned += removed
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Printf("\nStopped after %v ; %d runs, %d feeds fetched, %d failed, %d new posts, %d pruned\n", time.Since(start).Round(time.Second), summary.runs, summary.fetched, summary.failed, summary.newPosts, summary.pruned)
+			return nil
+		case <-time.After(nextWait(ctx, s)):
+		}
+	}
+}
+
+// aggOnce refreshes every due feed once and fails if any of them could not be
+// refreshed, so cron and systemd timers see a non-zero exit code.
+func aggOnce(ctx context.Context, s *state.State, sched rss.Schedule) error {
+	summary := aggSummary{}
+	if err := scrapeDue(ctx, s, sched, &summary); err != nil {
+		return err
+	}
+
+	fmt.Printf("Refreshed %d due feeds, %d failed, %d new posts\n", summary.fetched, summary.failed, summary.newPosts)
+
+	if summary.failed > 0 {
+		return fmt.Errorf("%d feeds failed to refresh", summary.failed)
+	}
+
+	return nil
+}
+
+func aggFeed(ctx context.Context, s *state.State, feed_url string, sched rss.Schedule) error {
+	feed, err := s.DB.GetFeedByURL(ctx, feed_url)
+	if err != nil {
+		return err
+	}
+
+	if _, err := scrapeFeed(ctx, s, feed, sched, os.Stdout); err != nil {
+		return err
+	}
+
+	return nil
+}
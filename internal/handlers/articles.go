@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"io"
+	"fmt"
+	"time"
+	"errors"
+	"context"
+	"database/sql"
+	"gator/internal/state"
+	"gator/internal/article"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+)
+
+// fetchArticle downloads the full text of a post and stores it with the post.
+func fetchArticle(ctx context.Context, s *state.State, post_id int32, post_url string) (string, error) {
+	content, err := article.Fetch(ctx, s.Fetcher, post_url)
+	if err != nil {
+		return "", err
+	}
+
+	content_params := database.SetPostContentParams{
+		PostID:    post_id,
+		Content:   content,
+		FetchedAt: time.Now(),
+	}
+
+	if err := s.DB.SetPostContent(ctx, content_params); err != nil {
+		return "", err
+	}
+
+	return content, nil
+}
+
+// fetchArticles fetches the full text of new posts of a feed, a page that
+// fails is reported and skipped.
+func fetchArticles(ctx context.Context, s *state.State, feed database.Feed, posts []database.Post, out io.Writer) {
+	if len(posts) == 0 {
+		return
+	}
+
+	fetched := 0
+	for _, v := range posts {
+		if _, err := fetchArticle(ctx, s, v.ID, v.Url); err != nil {
+			fmt.Fprintf(out, "Error trying to fetch the article of post %d - %s ; %v\n", v.ID, v.Url, err)
+			continue
+		}
+
+		fetched++
+	}
+
+	fmt.Fprintf(out, "Fetched full text of %d of %d new posts from - %s\n", fetched, len(posts), feed.Name)
+}
+
+func handlerFetchArticle(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected post id")
+	}
+
+	force := len(cmd.args) > 1 && cmd.args[1] == "--force"
+
+	ctx := context.Background()
+
+	post, err := followedPost(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	stored, err := s.DB.GetPostContent(ctx, post.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	content := stored.Content
+	if err != nil || force {
+		content, err = fetchArticle(ctx, s, post.ID, post.Url)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Fetched the article of post %d - %s\n", post.ID, sanitize.StripControl(post.Title))
+	} else {
+		fmt.Printf("Article of post %d - %s ; fetched at %s\n", post.ID, sanitize.StripControl(post.Title), stored.FetchedAt.Local().Format(time.DateTime))
+	}
+
+	printBody(content, post.Url)
+
+	return nil
+}
+
+func handlerSetFullText(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected URL of the feed and on or off")
+	}
+
+	var full_text bool
+	switch clean_input(cmd.args[1]) {
+	case "on":
+		full_text = true
+	case "off":
+	default:
+		return fmt.Errorf("Expected on or off, got - %s", cmd.args[1])
+	}
+
+	ctx := context.Background()
+
+	feed, err := ownedFeed(ctx, s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	text_params := database.SetFeedFullTextParams{
+		ID:        feed.ID,
+		UpdatedAt: time.Now(),
+		FullText:  full_text,
+	}
+
+	if err := s.DB.SetFeedFullText(ctx, text_params); err != nil {
+		return err
+	}
+
+	if full_text {
+		fmt.Printf("Full text on for feed - %s ; articles of new posts are fetched on agg\n", feed.Name)
+	} else {
+		fmt.Printf("Full text off for feed - %s ; fetched articles are kept\n", feed.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"context"
+	"gator/internal/state"
+	"gator/internal/backup"
+	"gator/internal/database"
+)
+
+func handlerBackup(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected file name")
+	}
+
+	snap, err := backup.Take(context.Background(), s.DB)
+	if err != nil {
+		return err
+	}
+
+	if err := snap.WriteFile(cmd.args[0]); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up %d users, %d feeds, %d follows, %d posts to - %s\n", len(snap.Users), len(snap.Feeds), len(snap.Follows), len(snap.Posts), cmd.args[0])
+
+	return nil
+}
+
+func handlerRestore(s *state.State, cmd Command) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected file name")
+	}
+
+	snap, err := backup.ReadFile(cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := backup.Restore(context.Background(), s.DB, snap); err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %d users, %d feeds, %d follows, %d posts from backup of %s\n", len(snap.Users), len(snap.Feeds), len(snap.Follows), len(snap.Posts), snap.CreatedAt.Format("2006-01-02 15:04"))
+
+	return nil
+}
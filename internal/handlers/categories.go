@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"os"
+	"fmt"
+	"sort"
+	"time"
+	"context"
+	"gator/internal/opml"
+	"gator/internal/state"
+	"gator/internal/database"
+)
+
+// categoryFlag takes --category <name> out of args.
+func categoryFlag(args []string) (string, []string, error) {
+	var category string
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--category" {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("Expected a category after --category")
+		}
+
+		category = clean_input(args[i+1])
+		i++
+	}
+
+	return category, rest, nil
+}
+
+// followCategories maps every feed the user follows to its categories.
+func followCategories(ctx context.Context, s *state.State, user database.User) (map[int32][]string, error) {
+	rows, err := s.DB.GetFollowCategoriesForUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int32][]string)
+	for _, v := range rows {
+		res[v.FeedID] = append(res[v.FeedID], v.Name)
+	}
+
+	return res, nil
+}
+
+// followedFeeds returns the feeds the user follows, only those in category
+// unless it is empty.
+func followedFeeds(ctx context.Context, s *state.State, user database.User, category string) ([]database.Feed, error) {
+	if category == "" {
+		return s.DB.GetFollowedFeedsForUser(ctx, user.ID)
+	}
+
+	if _, err := s.DB.GetCategory(ctx, database.GetCategoryParams{UserID: user.ID, Name: category}); err != nil {
+		return nil, fmt.Errorf("No category - %s", category)
+	}
+
+	return s.DB.GetFollowedFeedsInCategory(ctx, database.GetFollowedFeedsInCategoryParams{UserID: user.ID, Name: category})
+}
+
+func createCategory(ctx context.Context, s *state.State, user database.User, name string) (database.Category, error) {
+	c_time := time.Now()
+
+	cat_params := database.CreateCategoryParams{
+		CreatedAt: c_time,
+		UpdatedAt: c_time,
+		UserID:    user.ID,
+		Name:      name,
+	}
+
+	return s.DB.CreateCategory(ctx, cat_params)
+}
+
+func handlerCategory(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected add, rm or list")
+	}
+
+	ctx := context.Background()
+
+	switch cmd.args[0] {
+	case "add":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("Expected category name")
+		}
+
+		category, err := createCategory(ctx, s, user, clean_input(cmd.args[1]))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Created category -", category.Name)
+	case "rm":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("Expected category name")
+		}
+
+		name := clean_input(cmd.args[1])
+
+		n, err := s.DB.DeleteCategory(ctx, database.DeleteCategoryParams{UserID: user.ID, Name: name})
+		if err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("No category - %s", name)
+		}
+
+		fmt.Println("Removed category -", name, "; its feeds are still followed")
+	case "list":
+		categories, err := s.DB.GetCategoriesForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if len(categories) == 0 {
+			fmt.Println("No categories yet, create one with 'category add <name>'")
+			return nil
+		}
+
+		for _, v := range categories {
+			fmt.Printf("* %s (%d feeds)\n", v.Name, v.Feeds)
+		}
+	default:
+		return fmt.Errorf("Expected add, rm or list")
+	}
+
+	return nil
+}
+
+func handlerCategorize(s *state.State, cmd Command, user database.User) error {
+	remove := false
+
+	var args []string
+	for _, v := range cmd.args {
+		if v == "--remove" {
+			remove = true
+			continue
+		}
+		args = append(args, v)
+	}
+
+	if len(args) < 2 {
+		return fmt.Errorf("Expected URL of a followed feed and category name")
+	}
+
+	ctx := context.Background()
+
+	feed, err := s.DB.GetFeedByURL(ctx, clean_input(args[0]))
+	if err != nil {
+		return err
+	}
+
+	follow, err := s.DB.GetFeedFollow(ctx, database.GetFeedFollowParams{UserID: user.ID, FeedID: feed.ID})
+	if err != nil {
+		return fmt.Errorf("Not following feed - %s", feed.Name)
+	}
+
+	name := clean_input(args[1])
+
+	category, err := s.DB.GetCategory(ctx, database.GetCategoryParams{UserID: user.ID, Name: name})
+	if err != nil && remove {
+		return fmt.Errorf("No category - %s", name)
+	} else if err != nil {
+		// Categorizing into a new category creates it.
+		if category, err = createCategory(ctx, s, user, name); err != nil {
+			return err
+		}
+
+		fmt.Println("Created category -", category.Name)
+	}
+
+	if remove {
+		n, err := s.DB.UncategorizeFollow(ctx, database.UncategorizeFollowParams{FeedFollowID: follow.ID, CategoryID: category.ID})
+		if err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("Feed - %s ; is not in category - %s", feed.Name, category.Name)
+		}
+
+		fmt.Printf("Removed feed - %s ; from category - %s\n", feed.Name, category.Name)
+
+		return nil
+	}
+
+	if err := s.DB.CategorizeFollow(ctx, database.CategorizeFollowParams{FeedFollowID: follow.ID, CategoryID: category.ID}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added feed - %s ; to category - %s\n", feed.Name, category.Name)
+
+	return nil
+}
+
+func handlerExportOPML(s *state.State, cmd Command, user database.User) error {
+	category, _, err := categoryFlag(cmd.args)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	feeds, err := followedFeeds(ctx, s, user, category)
+	if err != nil {
+		return err
+	}
+
+	categories, err := followCategories(ctx, s, user)
+	if err != nil {
+		return err
+	}
+
+	doc := opml.Document{
+		Title:       "Feeds followed by " + user.Name,
+		DateCreated: time.Now().Format(time.RFC1123Z),
+	}
+
+	// Feeds go into a folder per category, uncategorized ones at the top.
+	var folders []string
+	by_folder := make(map[string][]opml.Outline)
+	for _, v := range feeds {
+		outline := opml.Feed(v.Name, v.Url, v.SiteLink)
+
+		if len(categories[v.ID]) == 0 {
+			doc.Outlines = append(doc.Outlines, outline)
+			continue
+		}
+
+		for _, name := range categories[v.ID] {
+			if category != "" && name != category {
+				continue
+			}
+
+			if _, ok := by_folder[name]; !ok {
+				folders = append(folders, name)
+			}
+			by_folder[name] = append(by_folder[name], outline)
+		}
+	}
+
+	sort.Strings(folders)
+	for _, name := range folders {
+		doc.Outlines = append(doc.Outlines, opml.Folder(name, by_folder[name]))
+	}
+
+	return opml.Write(os.Stdout, doc)
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"io"
+	"fmt"
+	"time"
+	"context"
+	"strconv"
+	"database/sql"
+	"gator/internal/state"
+	"gator/internal/filter"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+)
+
+// filterTestPosts is how many of the latest posts 'filter test' checks.
+const filterTestPosts = 500
+
+// feedRules returns the compiled filters of every user following the feed.
+// A broken rule is reported and skipped rather than failing the fetch.
+func feedRules(ctx context.Context, s *state.State, feed database.Feed, out io.Writer) ([]filter.Rule, error) {
+	filters, err := s.DB.GetFiltersForFeed(ctx, sql.NullInt32{Int32: feed.ID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []filter.Rule
+	for _, v := range filters {
+		rule, err := filter.Compile(v)
+		if err != nil {
+			fmt.Fprintln(out, "Skipping filter -", err)
+			continue
+		}
+
+		res = append(res, rule)
+	}
+
+	return res, nil
+}
+
+// applyRules runs the action of every rule matching a new post, returning
+// how many matched.
+func applyRules(ctx context.Context, qtx database.Store, rules []filter.Rule, post database.Post) (int, error) {
+	matched := 0
+
+	for _, v := range rules {
+		if !v.Match(post.Title, post.Description) {
+			continue
+		}
+		matched++
+
+		user_id, c_time := v.Filter.UserID, time.Now()
+
+		var err error
+		switch v.Action.Kind {
+		case filter.ActionHide:
+			err = qtx.HidePost(ctx, database.HidePostParams{UserID: user_id, PostID: post.ID, UpdatedAt: c_time})
+		case filter.ActionMarkRead:
+			err = qtx.SetPostRead(ctx, database.SetPostReadParams{UserID: user_id, PostID: post.ID, Read: true, UpdatedAt: c_time})
+		case filter.ActionStar:
+			err = qtx.SetPostStarred(ctx, database.SetPostStarredParams{UserID: user_id, PostID: post.ID, Starred: true, UpdatedAt: c_time})
+		case filter.ActionTag:
+			err = tagPost(ctx, qtx, user_id, post.ID, v.Action.Tag)
+		}
+		if err != nil {
+			return matched, err
+		}
+	}
+
+	return matched, nil
+}
+
+// filterArgs holds the flags shared by 'filter add' and 'filter test'.
+type filterArgs struct {
+	feed    *database.Feed
+	field   string
+	pattern string
+	action  string
+}
+
+func parseFilterArgs(ctx context.Context, s *state.State, args []string) (filterArgs, error) {
+	var res filterArgs
+
+	for i := 0; i < len(args); i++ {
+		if i+1 >= len(args) {
+			return filterArgs{}, fmt.Errorf("Expected a value after %s", args[i])
+		}
+
+		value := clean_input(args[i+1])
+
+		if args[i] != "--feed" && args[i] != "--action" && res.pattern != "" {
+			return filterArgs{}, fmt.Errorf("Expected only one of --title-regex, --content-regex, --regex or --keyword")
+		}
+
+		switch args[i] {
+		case "--feed":
+			feed, err := s.DB.GetFeedByURL(ctx, value)
+			if err != nil {
+				return filterArgs{}, fmt.Errorf("No feed - %s", value)
+			}
+			res.feed = &feed
+		case "--title-regex":
+			res.field, res.pattern = filter.FieldTitle, value
+		case "--content-regex":
+			res.field, res.pattern = filter.FieldContent, value
+		case "--regex":
+			res.field, res.pattern = filter.FieldAny, value
+		case "--keyword":
+			res.field, res.pattern = filter.FieldAny, filter.Keyword(value)
+		case "--action":
+			res.action = value
+		default:
+			return filterArgs{}, fmt.Errorf("Unknown flag - %s", args[i])
+		}
+		i++
+	}
+
+	if res.pattern == "" {
+		return filterArgs{}, fmt.Errorf("Expected --title-regex, --content-regex, --regex or --keyword")
+	}
+
+	return res, nil
+}
+
+// toFilter turns the flags into a filter of user, checking it compiles.
+func (f filterArgs) toFilter(user database.User) (filter.Rule, error) {
+	res := database.Filter{
+		UserID:  user.ID,
+		Field:   f.field,
+		Pattern: f.pattern,
+		Action:  f.action,
+	}
+
+	if f.feed != nil {
+		res.FeedID = sql.NullInt32{Int32: f.feed.ID, Valid: true}
+	}
+
+	if res.Action == "" {
+		// 'filter test' doesn't need an action.
+		res.Action = filter.ActionHide
+	}
+
+	return filter.Compile(res)
+}
+
+func filterScope(ctx context.Context, s *state.State, f database.Filter) string {
+	if !f.FeedID.Valid {
+		return "all followed feeds"
+	}
+
+	feed, err := s.DB.GetFeedByID(ctx, f.FeedID.Int32)
+	if err != nil {
+		return fmt.Sprintf("feed %d", f.FeedID.Int32)
+	}
+
+	return "feed " + feed.Name
+}
+
+func handlerFilter(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected add, rm, list or test")
+	}
+
+	ctx := context.Background()
+
+	switch cmd.args[0] {
+	case "add":
+		return filterAdd(ctx, s, cmd.args[1:], user)
+	case "rm":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("Expected filter id")
+		}
+
+		id, err := strconv.ParseInt(clean_input(cmd.args[1]), 10, 32)
+		if err != nil {
+			return fmt.Errorf("Expected filter id, got - %s", cmd.args[1])
+		}
+
+		n, err := s.DB.DeleteFilter(ctx, database.DeleteFilterParams{ID: int32(id), UserID: user.ID})
+		if err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("No filter - %d", id)
+		}
+
+		fmt.Println("Removed filter -", id)
+	case "list":
+		filters, err := s.DB.GetFiltersForUser(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		if len(filters) == 0 {
+			fmt.Println("No filters yet, create one with 'filter add'")
+			return nil
+		}
+
+		for _, v := range filters {
+			fmt.Printf("* %d - %s matches %s ; %s ; on %s\n", v.ID, v.Field, v.Pattern, v.Action, filterScope(ctx, s, v))
+		}
+	case "test":
+		return filterTest(ctx, s, cmd.args[1:], user)
+	default:
+		return fmt.Errorf("Expected add, rm, list or test")
+	}
+
+	return nil
+}
+
+func filterAdd(ctx context.Context, s *state.State, args []string, user database.User) error {
+	f_args, err := parseFilterArgs(ctx, s, args)
+	if err != nil {
+		return err
+	}
+
+	if f_args.action == "" {
+		return fmt.Errorf("Expected --action hide, markread, star or tag:<name>")
+	}
+
+	rule, err := f_args.toFilter(user)
+	if err != nil {
+		return err
+	}
+
+	c_time := time.Now()
+
+	filter_params := database.CreateFilterParams{
+		CreatedAt: c_time,
+		UpdatedAt: c_time,
+		UserID:    user.ID,
+		FeedID:    rule.Filter.FeedID,
+		Field:     rule.Filter.Field,
+		Pattern:   rule.Filter.Pattern,
+		Action:    rule.Action.String(),
+	}
+
+	res, err := s.DB.CreateFilter(ctx, filter_params)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Created filter - %d ; %s on new posts of %s, see matching posts with 'filter test %d'\n", res.ID, res.Action, filterScope(ctx, s, res), res.ID)
+
+	return nil
+}
+
+// filterTest lists which of the latest posts a saved filter, or one given by
+// flags, would catch, without changing anything.
+func filterTest(ctx context.Context, s *state.State, args []string, user database.User) error {
+	if len(args) == 0 {
+		return fmt.Errorf("Expected filter id or the flags of 'filter add'")
+	}
+
+	var rule filter.Rule
+
+	if id, err := strconv.ParseInt(clean_input(args[0]), 10, 32); err == nil {
+		saved, err := s.DB.GetFilter(ctx, database.GetFilterParams{ID: int32(id), UserID: user.ID})
+		if err != nil {
+			return fmt.Errorf("No filter - %d", id)
+		}
+
+		if rule, err = filter.Compile(saved); err != nil {
+			return err
+		}
+	} else {
+		f_args, err := parseFilterArgs(ctx, s, args)
+		if err != nil {
+			return err
+		}
+
+		if rule, err = f_args.toFilter(user); err != nil {
+			return err
+		}
+	}
+
+	type candidate struct {
+		id    int32
+		title string
+		url   string
+	}
+
+	var posts []candidate
+	var checked int
+
+	if rule.Filter.FeedID.Valid {
+		rows, err := s.DB.GetFeedPostsForUser(ctx, database.GetFeedPostsForUserParams{UserID: user.ID, FeedID: rule.Filter.FeedID.Int32, Limit: filterTestPosts})
+		if err != nil {
+			return err
+		}
+
+		checked = len(rows)
+		for _, v := range rows {
+			if rule.Match(v.Title, v.Description) {
+				posts = append(posts, candidate{v.ID, sanitize.StripControl(v.Title), sanitize.StripControl(v.Url)})
+			}
+		}
+	} else {
+		rows, err := s.DB.GetRiverForUser(ctx, database.GetRiverForUserParams{UserID: user.ID, Limit: filterTestPosts})
+		if err != nil {
+			return err
+		}
+
+		checked = len(rows)
+		for _, v := range rows {
+			if rule.Match(v.Title, v.Description) {
+				posts = append(posts, candidate{v.ID, sanitize.StripControl(v.Title), sanitize.StripControl(v.Url)})
+			}
+		}
+	}
+
+	fmt.Printf("%d of the latest %d posts of %s match - %s\n", len(posts), checked, filterScope(ctx, s, rule.Filter), rule.Filter.Pattern)
+
+	for _, v := range posts {
+		fmt.Printf("* %d - %s (URL:%s)\n", v.id, v.title, v.url)
+	}
+
+	return nil
+}
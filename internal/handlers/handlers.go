@@ -1,13 +1,21 @@
 package handlers
 
 import (
-	"os"
+	"io"
 	"fmt"
 	"log"
 	"time"
 	"context"
-	"gator/internal/rss"
+	"strings"
+	"net/http"
+	"database/sql"
+	"gator/internal/tui"
+	"gator/internal/web"
+	"gator/internal/fetch"
+	"gator/internal/fever"
 	"gator/internal/state"
+	"gator/internal/migrate"
+	"gator/internal/sanitize"
 	"gator/internal/database"
 
 	"github.com/google/uuid"
@@ -22,6 +30,10 @@ type Commands struct {
 	cmd map[string]func(*state.State, Command) error
 }
 
+func (c Command) Name() string {
+	return c.name
+}
+
 func (c *Commands) run(s *state.State, cmd Command) error {
 	get_cmd := c.cmd[cmd.name]
 
@@ -65,11 +77,24 @@ func handlerRegisters(s *state.State, cmd Command) error {
 
 	curr_time := time.Now()
 
+	// Without an admin yet, the new user becomes one so the install can be
+	// administered at all.
+	admins, err := s.DB.CountAdmins(context.Background())
+	if err != nil {
+		return err
+	}
+
+	role := database.RoleMember
+	if admins == 0 {
+		role = database.RoleAdmin
+	}
+
 	user_params := database.CreateUserParams{
 		ID:        uuid.New(),
 		CreatedAt: curr_time,
 		UpdatedAt: curr_time,
 		Name:      cmd.args[0],
+		Role:      role,
 	}
 
 	if _, err := s.DB.CreateUser(context.Background(), user_params); err != nil {
@@ -80,23 +105,13 @@ func handlerRegisters(s *state.State, cmd Command) error {
 		return err
 	}
 
-	fmt.Println("Successfully created and logged into user :","\nid:", user_params.ID, "\ncreated_at:", user_params.CreatedAt, "\nupdated_at:", user_params.UpdatedAt, "\nname:", user_params.Name)
-
-	return nil
-}
-
-func handlerResets(s *state.State, cmd Command) error {
-	if err := s.DB.ResetUsers(context.Background()); err != nil {
-		return err
-	}
-
-	fmt.Println("Successfully cleaned all tables in the database")
+	fmt.Println("Successfully created and logged into user :","\nid:", user_params.ID, "\ncreated_at:", user_params.CreatedAt, "\nupdated_at:", user_params.UpdatedAt, "\nname:", user_params.Name, "\nrole:", user_params.Role)
 
 	return nil
 }
 
 func handlerUsers(s *state.State, cmd Command) error {
-	users, err := s.DB.GetUsers(context.Background())
+	users, err := s.DB.GetAllUsers(context.Background())
 	if err != nil {
 		return err
 	}
@@ -107,93 +122,17 @@ func handlerUsers(s *state.State, cmd Command) error {
 	}
 
 	for _, v := range users {
-		if v == s.Cfg.Curr_Username {
-			fmt.Printf("* %s (current)\n", v)
-			continue
+		role := ""
+		if v.Role == database.RoleAdmin {
+			role = " [admin]"
 		}
 
-		fmt.Printf("* %s\n", v)
-	}
-
-	return nil
-}
-
-func scrapeFeeds(s *state.State, cmd Command) error {
-	fmt.Println("Getting the next feed to be fetched...")
-
-	feed, err := s.DB.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		return err
-	}
-
-	c_time := time.Now()
-
-	fmt.Printf("Fetching the feed - %s\n", feed.Name)
-
-	feed_params := database.MarkFeedFetchedParams{
-		ID:        feed.ID,
-		UpdatedAt: c_time,
-	}
-
-	if err := s.DB.MarkFeedFetched(context.Background(), feed_params); err != nil {
-		return err
-	}
-
-	ctx := context.Background()
-
-	res, err := rss.FetchFeed(&ctx, feed.Url)
-	if err != nil {
-		return err
-	}
-
-	for _, v := range res.Channel.Item {
-		s_time := time.Now()
-
-		p_time, err := time.Parse(time.RFC1123Z, v.PubDate)
-		if err != nil {
-			fmt.Println("Error trying to parse time of a post -", err)
+		if v.Name == s.Cfg.Curr_Username {
+			fmt.Printf("* %s%s (current)\n", v.Name, role)
 			continue
 		}
 
-		post_params := database.CreatePostParams{
-			CreatedAt:   s_time,
-			UpdatedAt:   s_time,
-			Title:       v.Title,
-			Url:         v.Link,
-			Description: v.Description,
-			PublishedAt: p_time,
-			FeedID:      feed.ID,
-		}
-
-		if err := s.DB.CreatePost(context.Background(), post_params); err != nil {
-			fmt.Println("Error trying to insert a post -", err)
-		}
-	}
-
-	return nil
-}
-
-func handlerAgg(s *state.State, cmd Command) error {
-	var b_time time.Duration
-
-	if len(cmd.args) == 0 {
-		return fmt.Errorf("Expected arguments")
-	} else if len(cmd.args) == 1 {
-		c_time, err := time.ParseDuration(cmd.args[0]) 
-		if err != nil {
-			return err
-		}
-
-		b_time = c_time
-	} else {
-		return fmt.Errorf("Too many arguments, expected 1: time")
-	}
-
-	fmt.Printf("Collecting feeds every %v\n", b_time)
-
-	ticker := time.NewTicker(b_time)
-	for range ticker.C {
-		scrapeFeeds(s, cmd)
+		fmt.Printf("* %s%s\n", v.Name, role)
 	}
 
 	return nil
@@ -214,6 +153,10 @@ func handlerAddFeed(s *state.State, cmd Command, user database.User) error {
 	
 	url, name := clean_input(cmd.args[1]), clean_input(cmd.args[0])
 
+	if err := fetch.CheckURL(url); err != nil {
+		return err
+	}
+
 	c_time := time.Now()
 
 	feed := database.CreateFeedParams{
@@ -258,11 +201,41 @@ func handlerFeeds(s *state.State, cmd Command) error {
 		}
 
 		fmt.Printf("#%v : Name - %s ; URL - %s ; User - %s (UID:%v)\n", v.ID, v.Name, v.Url, user.Name, v.UserID)
+		printFeedMetadata(v)
 	}
 
 	return nil
 }
 
+// printFeedMetadata prints what the publisher says about the feed, as stored
+// on its last successful fetch, and what was wrong with it.
+func printFeedMetadata(feed database.Feed) {
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Title", feed.Title},
+		{"Site", feed.SiteLink},
+		{"Description", feed.Description},
+		{"Language", feed.Language},
+		{"Image", feed.ImageUrl},
+		{"Generator", feed.Generator},
+	}
+
+	for _, v := range fields {
+		if v.value != "" {
+			fmt.Printf("    %s - %s\n", v.label, strings.Join(strings.Fields(sanitize.StripControl(v.value)), " "))
+		}
+	}
+
+	// Warnings of the lenient parser, one per line.
+	if feed.ParseWarnings != "" {
+		for _, v := range strings.Split(feed.ParseWarnings, "\n") {
+			fmt.Printf("    Warning - %s\n", sanitize.StripControl(v))
+		}
+	}
+}
+
 func handlerFollow(s *state.State, cmd Command, user database.User) error {
 	if len(cmd.args) == 0 {
 		return fmt.Errorf("Expected URL")
@@ -293,14 +266,29 @@ func handlerFollow(s *state.State, cmd Command, user database.User) error {
 }
 
 func handlerFollowing(s *state.State, cmd Command, user database.User) error {
-	feeds, err := s.DB.GetFeedFollowsForUser(context.Background(), user.ID)
+	category, _, err := categoryFlag(cmd.args)
+	if err != nil {
+		return err
+	}
+
+	feeds, err := followedFeeds(context.Background(), s, user, category)
+	if err != nil {
+		return err
+	}
+
+	categories, err := followCategories(context.Background(), s, user)
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("Currently followed feeds on User - %s (ID:%v)\n", user.Name, user.ID)
 	for _, v := range feeds {
-		fmt.Printf("Feed - %s ; ID - %d\n", v.FeedName, v.FeedID)
+		if len(categories[v.ID]) > 0 {
+			fmt.Printf("Feed - %s ; ID - %d ; Categories - %s\n", v.Name, v.ID, strings.Join(categories[v.ID], ", "))
+		} else {
+			fmt.Printf("Feed - %s ; ID - %d\n", v.Name, v.ID)
+		}
+		printFeedMetadata(v)
 	}
 
 	return nil
@@ -331,33 +319,216 @@ func handlerUnfollow(s *state.State, cmd Command, user database.User) error {
 }
 
 func handlerBrowse(s *state.State, cmd Command, user database.User) error {
+	category, args, err := categoryFlag(cmd.args)
+	if err != nil {
+		return err
+	}
+
+	tag, args, err := tagFlag(args)
+	if err != nil {
+		return err
+	}
+
+	if category != "" && tag != "" {
+		return fmt.Errorf("Expected either --category or --tag")
+	}
+
 	var limit int32
 
-	if len(cmd.args) == 0 {
+	if len(args) == 0 {
 		limit = 2
 	} else {
 		limit = 0
 
-		c_i := clean_input(cmd.args[0])
+		c_i := clean_input(args[0])
 		for _, v := range c_i {
 			limit = limit*10 + int32(v - '0')
 		}
 	}
 
-	fmt.Printf("Displaying last %d posts from subscribed feeds...\n", limit)
+	var posts []database.Post
 
-	browse_params := database.GetPostsByUserParams{
-		Name: user.Name,
-		Limit: limit,
-	}
+	if tag != "" {
+		fmt.Printf("Displaying last %d posts tagged %s...\n", limit, tag)
+
+		browse_params := database.GetPostsByUserWithTagParams{
+			UserID: user.ID,
+			Name:   tag,
+			Limit:  limit,
+		}
+
+		posts, err = s.DB.GetPostsByUserWithTag(context.Background(), browse_params)
+	} else if category != "" {
+		fmt.Printf("Displaying last %d posts from subscribed feeds in category %s...\n", limit, category)
+
+		browse_params := database.GetPostsByUserInCategoryParams{
+			UserID: user.ID,
+			Name:   category,
+			Limit:  limit,
+		}
+
+		posts, err = s.DB.GetPostsByUserInCategory(context.Background(), browse_params)
+	} else {
+		fmt.Printf("Displaying last %d posts from subscribed feeds...\n", limit)
 
-	posts, err := s.DB.GetPostsByUser(context.Background(), browse_params)
+		browse_params := database.GetPostsByUserParams{
+			Name: user.Name,
+			Limit: limit,
+		}
+
+		posts, err = s.DB.GetPostsByUser(context.Background(), browse_params)
+	}
 	if err != nil {
 		return err
 	}
 
 	for _, v := range posts {
-		fmt.Println(v)
+		printPost(v)
+	}
+
+	return nil
+}
+
+// browseWidth is the width post bodies are wrapped to by browse.
+const browseWidth = 78
+
+func printPost(post database.Post) {
+	fmt.Printf("\n* %d - %s\n  %s ; %s\n", post.ID, sanitize.StripControl(post.Title), post.PublishedAt.Local().Format("2006-01-02 15:04"), sanitize.StripControl(post.Url))
+
+	printBody(post.Description, post.Url)
+}
+
+// printBody prints an HTML body as indented plain text, links resolved
+// against base.
+func printBody(body, base string) {
+	body = sanitize.Text(body, base, browseWidth-2)
+	if body == "" {
+		return
+	}
+
+	fmt.Println()
+	for _, line := range strings.Split(body, "\n") {
+		fmt.Println(strings.TrimRight("  "+line, " "))
+	}
+}
+
+func handlerAPIKey(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected password")
+	}
+
+	key_params := database.SetUserAPIKeyParams{
+		ID:        user.ID,
+		UpdatedAt: time.Now(),
+		ApiKey:    sql.NullString{String: fever.APIKey(user.Name, clean_input(cmd.args[0])), Valid: true},
+	}
+
+	if err := s.DB.SetUserAPIKey(context.Background(), key_params); err != nil {
+		return err
+	}
+
+	fmt.Printf("Fever API enabled for user - %s ; log in with username \"%s\" and the given password\n", user.Name, user.Name)
+
+	return nil
+}
+
+func handlerServe(s *state.State, cmd Command) error {
+	addr := ":8080"
+	if len(cmd.args) > 0 {
+		addr = clean_input(cmd.args[0])
+	}
+
+	web_ui, err := web.New(s.DB, s.Fetcher)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/fever/", &fever.Server{DB: s.DB})
+	mux.Handle("/", web_ui.Handler())
+
+	fmt.Printf("Serving web interface on %s/ and Fever API on %s/fever/\n", addr, addr)
+
+	return http.ListenAndServe(addr, mux)
+}
+
+func handlerTUI(s *state.State, cmd Command, user database.User) error {
+	reader := tui.Reader{
+		DB:   s.DB,
+		User: user,
+		Refresh: func(ctx context.Context, feedID int32) error {
+			feed, err := s.DB.GetFeedByID(ctx, feedID)
+			if err != nil {
+				return err
+			}
+
+			// Progress reports would tear the screen.
+			_, err = scrapeFeed(ctx, s, feed, fetchSchedule(s), io.Discard)
+
+			return err
+		},
+	}
+
+	return reader.Run(context.Background())
+}
+
+func handlerMigrate(s *state.State, cmd Command) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected up, down or status")
+	}
+
+	dialect := migrate.ForDriver(s.Driver)
+
+	migs, err := dialect.Load()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	// status only reads, up and down change the schema of every user.
+	if cmd.args[0] == "up" || cmd.args[0] == "down" {
+		if err := requireSetup(s, cmd); err != nil {
+			return err
+		}
+	}
+
+	switch cmd.args[0] {
+	case "up":
+		applied, err := dialect.Up(ctx, s.Conn, migs)
+		for _, v := range applied {
+			fmt.Println("Applied migration -", v.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("Database is already up to date at version", migrate.Latest(migs))
+		}
+	case "down":
+		rolled, err := dialect.Down(ctx, s.Conn, migs)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Rolled back migration -", rolled.Name)
+	case "status":
+		statuses, err := dialect.List(ctx, s.Conn, migs)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range statuses {
+			applied := "pending"
+			if v.Applied {
+				applied = "applied"
+			}
+
+			fmt.Printf("%-8s %s\n", applied, v.Migration.Name)
+		}
+	default:
+		return fmt.Errorf("Expected up, down or status")
 	}
 
 	return nil
@@ -374,11 +545,63 @@ func middlewareLoggedIn(handler func(s *state.State, cmd Command, user database.
 	}
 }
 
+// middlewareAdmin is middlewareLoggedIn for commands that affect every user,
+// which only admins may run.
+func middlewareAdmin(handler func(s *state.State, cmd Command, user database.User) error) func(*state.State, Command) error {
+	return middlewareLoggedIn(func(s *state.State, c Command, user database.User) error {
+		if user.Role != database.RoleAdmin {
+			return fmt.Errorf("Only admins can run %s, %s is a %s", c.name, user.Name, user.Role)
+		}
+
+		return handler(s, c, user)
+	})
+}
+
+// rolesVersion is the migration adding users.role, 011_users.sql.
+const rolesVersion = 11
+
+// requireSetup lets only admins go on, unless the database has no users yet:
+// a new database is migrated and restored into before anyone can log in. A
+// database from before roles has no admins, and users can't be read from it
+// until it is migrated, so anyone may upgrade it.
+func requireSetup(s *state.State, c Command) error {
+	ctx := context.Background()
+
+	version, err := migrate.ForDriver(s.Driver).Current(ctx, s.Conn)
+	if err != nil || version < rolesVersion {
+		return err
+	}
+
+	users, err := s.DB.GetUsers(ctx)
+	if err != nil || len(users) == 0 {
+		return err
+	}
+
+	return middlewareAdmin(func(*state.State, Command, database.User) error { return nil })(s, c)
+}
+
+// middlewareSetup is middlewareAdmin for the commands setting up a database,
+// see requireSetup.
+func middlewareSetup(handler func(s *state.State, cmd Command) error) func(*state.State, Command) error {
+	return func(s *state.State, c Command) error {
+		if err := requireSetup(s, c); err != nil {
+			return err
+		}
+
+		return handler(s, c)
+	}
+}
+
 func (c *Commands) Register_all_cmds() {
+	c.register("init", handlerInit)
+	c.register("profile", handlerProfile)
 	c.register("login", handlerLogins)
 	c.register("register", handlerRegisters)
-	c.register("reset", handlerResets)
+	c.register("reset", middlewareAdmin(handlerResets))
 	c.register("users", handlerUsers)
+	c.register("deleteuser", middlewareAdmin(handlerDeleteUser))
+	c.register("renameuser", middlewareAdmin(handlerRenameUser))
+	c.register("setrole", middlewareAdmin(handlerSetRole))
 	c.register("agg", handlerAgg)
 	c.register("addfeed", middlewareLoggedIn(handlerAddFeed))
 	c.register("feeds", handlerFeeds)
@@ -386,10 +609,29 @@ func (c *Commands) Register_all_cmds() {
 	c.register("following", middlewareLoggedIn(handlerFollowing))
 	c.register("unfollow", middlewareLoggedIn(handlerUnfollow))
 	c.register("browse", middlewareLoggedIn(handlerBrowse))
+	c.register("category", middlewareLoggedIn(handlerCategory))
+	c.register("categorize", middlewareLoggedIn(handlerCategorize))
+	c.register("exportopml", middlewareLoggedIn(handlerExportOPML))
+	c.register("tag", middlewareLoggedIn(handlerTag))
+	c.register("untag", middlewareLoggedIn(handlerUntag))
+	c.register("tags", middlewareLoggedIn(handlerTags))
+	c.register("filter", middlewareLoggedIn(handlerFilter))
+	c.register("fetch-article", middlewareLoggedIn(handlerFetchArticle))
+	c.register("setfulltext", middlewareLoggedIn(handlerSetFullText))
+	c.register("apikey", middlewareLoggedIn(handlerAPIKey))
+	c.register("serve", handlerServe)
+	c.register("tui", middlewareLoggedIn(handlerTUI))
+	c.register("migrate", handlerMigrate)
+	c.register("renamefeed", middlewareLoggedIn(handlerRenameFeed))
+	c.register("setfeedurl", middlewareLoggedIn(handlerSetFeedURL))
+	c.register("deletefeed", middlewareLoggedIn(handlerDeleteFeed))
+	c.register("backup", middlewareAdmin(handlerBackup))
+	c.register("restore", middlewareSetup(handlerRestore))
+	c.register("prune", middlewareAdmin(handlerPrune))
+	c.register("setretention", middlewareLoggedIn(handlerSetRetention))
 }
 
-func Handle_Input(new_cmds *Commands) (func(*state.State, Command) error, Command) {
-	os_args := os.Args
+func Handle_Input(new_cmds *Commands, os_args []string) (func(*state.State, Command) error, Command) {
 	if len(os_args) < 2 {
 		log.Fatal(fmt.Errorf("Expected arguments"))
 	} 
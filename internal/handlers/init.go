@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"os"
+	"fmt"
+	"bufio"
+	"errors"
+	"strings"
+	"path/filepath"
+	"gator/internal/state"
+)
+
+// defaultDBURL is a SQLite database under $XDG_DATA_HOME, which needs no
+// server to get started.
+func defaultDBURL() (string, error) {
+	data_dir := os.Getenv("XDG_DATA_HOME")
+	if data_dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		data_dir = filepath.Join(home, ".local", "share")
+	}
+
+	return "sqlite://" + filepath.Join(data_dir, "gator", "gator.db"), nil
+}
+
+func handlerInit(s *state.State, cmd Command) error {
+	var force bool
+	var db_url string
+
+	for _, v := range cmd.args {
+		if v == "--force" {
+			force = true
+			continue
+		}
+		db_url = v
+	}
+
+	path := s.Cfg.Path()
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("A config already exists at %s, pass --force to replace it", path)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if db_url == "" {
+		default_url, err := defaultDBURL()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Database URL, postgres://..., host=... or sqlite://<path> [%s]: ", default_url)
+
+		answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Println()
+		}
+
+		db_url = strings.TrimSpace(answer)
+		if db_url == "" {
+			db_url = default_url
+		}
+	}
+
+	s.Cfg.DB_URL = db_url
+	if err := s.Cfg.Validate(); err != nil {
+		return err
+	}
+
+	if path, ok := strings.CutPrefix(db_url, "sqlite://"); ok {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Cfg.Save(); err != nil {
+		return err
+	}
+
+	fmt.Println("Config written to -", path)
+	fmt.Println("Next, create the tables with 'gator migrate up' and a user with 'gator register <name>'")
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"gator/internal/fetch"
+	"gator/internal/state"
+	"gator/internal/database"
+)
+
+// ownedFeed looks up a feed the user may change, which is the user who added
+// it or an admin.
+func ownedFeed(ctx context.Context, s *state.State, feed_url string, user database.User) (database.Feed, error) {
+	feed, err := s.DB.GetFeedByURL(ctx, clean_input(feed_url))
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	if feed.UserID != user.ID && user.Role != database.RoleAdmin {
+		return database.Feed{}, fmt.Errorf("Only the user who added feed - %s ; or an admin can change it", feed.Name)
+	}
+
+	return feed, nil
+}
+
+// feedUsage counts the followers and posts a change to the feed affects.
+func feedUsage(ctx context.Context, s *state.State, feed database.Feed) (int64, int64, error) {
+	followers, err := s.DB.CountFeedFollowers(ctx, feed.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	posts, err := s.DB.CountPostsForFeed(ctx, feed.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return followers, posts, nil
+}
+
+func handlerRenameFeed(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected URL and new name of the feed")
+	}
+
+	ctx := context.Background()
+
+	feed, err := ownedFeed(ctx, s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	followers, _, err := feedUsage(ctx, s, feed)
+	if err != nil {
+		return err
+	}
+
+	name := clean_input(cmd.args[1])
+
+	rename_params := database.RenameFeedParams{
+		ID:        feed.ID,
+		UpdatedAt: time.Now(),
+		Name:      name,
+	}
+
+	if err := s.DB.RenameFeed(ctx, rename_params); err != nil {
+		return err
+	}
+
+	fmt.Printf("Renamed feed - %s ; to - %s ; seen by %d followers\n", feed.Name, name, followers)
+
+	return nil
+}
+
+func handlerSetFeedURL(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected current and new URL of the feed")
+	}
+
+	ctx := context.Background()
+
+	feed, err := ownedFeed(ctx, s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	followers, posts, err := feedUsage(ctx, s, feed)
+	if err != nil {
+		return err
+	}
+
+	url := clean_input(cmd.args[1])
+
+	if err := fetch.CheckURL(url); err != nil {
+		return err
+	}
+
+	url_params := database.SetFeedURLParams{
+		ID:        feed.ID,
+		UpdatedAt: time.Now(),
+		Url:       url,
+	}
+
+	if err := s.DB.SetFeedURL(ctx, url_params); err != nil {
+		return err
+	}
+
+	fmt.Printf("Moved feed - %s ; from - %s ; to - %s ; %d followers and %d posts kept, fetched again on the next agg run\n", feed.Name, feed.Url, url, followers, posts)
+
+	return nil
+}
+
+func handlerDeleteFeed(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected URL of the feed")
+	}
+
+	yes := len(cmd.args) > 1 && cmd.args[1] == "--yes"
+
+	ctx := context.Background()
+
+	feed, err := ownedFeed(ctx, s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	followers, posts, err := feedUsage(ctx, s, feed)
+	if err != nil {
+		return err
+	}
+
+	if err := confirm(fmt.Sprintf("delete feed %s, unfollowing it for %d users and removing %d posts", feed.Name, followers, posts), yes); err != nil {
+		return err
+	}
+
+	if err := backupBefore(ctx, s, "deletefeed"); err != nil {
+		return err
+	}
+
+	if _, err := s.DB.DeleteFeed(ctx, feed.ID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted feed - %s ; %d followers unfollowed, %d posts removed\n", feed.Name, followers, posts)
+
+	return nil
+}
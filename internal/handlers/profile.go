@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"fmt"
+	"gator/internal/state"
+	"gator/internal/config"
+)
+
+func handlerProfile(s *state.State, cmd Command) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected use, list, add or remove")
+	}
+
+	switch cmd.args[0] {
+	case "list":
+		default_profile := s.Cfg.Profile
+		if default_profile == "" {
+			default_profile = config.DefaultProfile
+		}
+
+		for _, name := range s.Cfg.ProfileNames() {
+			marks := ""
+			if name == default_profile {
+				marks += " (default)"
+			}
+
+			if name == s.Cfg.ActiveProfile() {
+				marks += " (in use)"
+			}
+
+			fmt.Printf("* %s%s ; %s\n", name, marks, s.Cfg.ProfileURL(name))
+		}
+	case "use":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("Expected profile name")
+		}
+
+		if err := s.Cfg.UseProfile(cmd.args[1]); err != nil {
+			return err
+		}
+
+		fmt.Println("Commands now use profile -", cmd.args[1])
+	case "add":
+		if len(cmd.args) < 3 {
+			return fmt.Errorf("Expected profile name and database URL")
+		}
+
+		if err := s.Cfg.AddProfile(cmd.args[1], cmd.args[2]); err != nil {
+			return err
+		}
+
+		fmt.Printf("Added profile - %s ; try it with 'gator --profile %s migrate status'\n", cmd.args[1], cmd.args[1])
+	case "remove":
+		if len(cmd.args) < 2 {
+			return fmt.Errorf("Expected profile name")
+		}
+
+		if err := s.Cfg.RemoveProfile(cmd.args[1]); err != nil {
+			return err
+		}
+
+		fmt.Println("Removed profile -", cmd.args[1])
+	default:
+		return fmt.Errorf("Expected use, list, add or remove")
+	}
+
+	return nil
+}
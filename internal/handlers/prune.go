@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"errors"
+	"strconv"
+	"context"
+	"database/sql"
+	"gator/internal/state"
+	"gator/internal/database"
+)
+
+// errDryRun rolls back the pruning transaction after counting what it removed.
+var errDryRun = errors.New("dry run")
+
+type retention struct {
+	days     int32
+	maxPosts int32
+}
+
+// retentionFor returns the feed's own retention policy where set and the
+// global one from the config otherwise; 0 keeps posts forever.
+func retentionFor(s *state.State, feed database.Feed) retention {
+	res := retention{days: s.Cfg.Retention_Days, maxPosts: s.Cfg.Retention_Max_Posts}
+
+	if feed.RetentionDays.Valid {
+		res.days = feed.RetentionDays.Int32
+	}
+
+	if feed.RetentionMaxPosts.Valid {
+		res.maxPosts = feed.RetentionMaxPosts.Int32
+	}
+
+	return res
+}
+
+func (r retention) cutoff() time.Time {
+	return time.Now().AddDate(0, 0, -int(r.days))
+}
+
+// retainedPosts drops the posts a prune with policy r would remove.
+func retainedPosts(r retention, posts []database.CreatePostParams) []database.CreatePostParams {
+	if r.days > 0 {
+		cutoff := r.cutoff()
+
+		kept := posts[:0]
+		for _, v := range posts {
+			if !v.PublishedAt.Before(cutoff) {
+				kept = append(kept, v)
+			}
+		}
+		posts = kept
+	}
+
+	if r.maxPosts > 0 && len(posts) > int(r.maxPosts) {
+		sort.SliceStable(posts, func(i, j int) bool { return posts[i].PublishedAt.After(posts[j].PublishedAt) })
+		posts = posts[:r.maxPosts]
+	}
+
+	return posts
+}
+
+// pruneFeeds applies every feed's retention policy, keeping posts starred or
+// tagged by any user, and returns how many posts were (or, for a dry run, would be)
+// removed.
+func pruneFeeds(ctx context.Context, s *state.State, dry_run bool) (int64, error) {
+	feeds, err := s.DB.GetFeeds(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	err = s.DB.InTx(ctx, func(qtx database.Store) error {
+		for _, feed := range feeds {
+			policy := retentionFor(s, feed)
+
+			var by_age, by_count int64
+			if policy.days > 0 {
+				age_params := database.DeletePostsPublishedBeforeParams{
+					FeedID:      feed.ID,
+					PublishedAt: policy.cutoff(),
+				}
+
+				n, err := qtx.DeletePostsPublishedBefore(ctx, age_params)
+				if err != nil {
+					return err
+				}
+				by_age = n
+			}
+
+			if policy.maxPosts > 0 {
+				limit_params := database.DeletePostsBeyondLimitParams{
+					FeedID: feed.ID,
+					Limit:  policy.maxPosts,
+				}
+
+				n, err := qtx.DeletePostsBeyondLimit(ctx, limit_params)
+				if err != nil {
+					return err
+				}
+				by_count = n
+			}
+
+			if by_age+by_count > 0 {
+				fmt.Printf("Feed - %s ; %d older than %d days, %d over the limit of %d posts\n", feed.Name, by_age, policy.days, by_count, policy.maxPosts)
+			}
+
+			total += by_age + by_count
+		}
+
+		if dry_run {
+			return errDryRun
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return 0, err
+	}
+
+	if dry_run {
+		fmt.Printf("Dry run, %d posts would be removed\n", total)
+	} else {
+		fmt.Printf("Removed %d posts\n", total)
+	}
+
+	return total, nil
+}
+
+func handlerPrune(s *state.State, cmd Command, user database.User) error {
+	dry_run := false
+	for _, v := range cmd.args {
+		if v != "--dry-run" {
+			return fmt.Errorf("Unknown argument - %s", v)
+		}
+		dry_run = true
+	}
+
+	_, err := pruneFeeds(context.Background(), s, dry_run)
+
+	return err
+}
+
+// parseRetention parses a retention value, "default" falls back to the
+// global setting.
+func parseRetention(s string) (sql.NullInt32, error) {
+	if s == "default" {
+		return sql.NullInt32{}, nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || n < 0 {
+		return sql.NullInt32{}, fmt.Errorf("Expected a number of 0 or more, or default - %s", s)
+	}
+
+	return sql.NullInt32{Int32: int32(n), Valid: true}, nil
+}
+
+func handlerSetRetention(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 3 {
+		return fmt.Errorf("Expected URL, days and max posts")
+	}
+
+	feed, err := ownedFeed(context.Background(), s, cmd.args[0], user)
+	if err != nil {
+		return err
+	}
+
+	days, err := parseRetention(cmd.args[1])
+	if err != nil {
+		return err
+	}
+
+	max_posts, err := parseRetention(cmd.args[2])
+	if err != nil {
+		return err
+	}
+
+	ret_params := database.SetFeedRetentionParams{
+		ID:                feed.ID,
+		UpdatedAt:         time.Now(),
+		RetentionDays:     days,
+		RetentionMaxPosts: max_posts,
+	}
+
+	if err := s.DB.SetFeedRetention(context.Background(), ret_params); err != nil {
+		return err
+	}
+
+	feed.RetentionDays, feed.RetentionMaxPosts = days, max_posts
+	policy := retentionFor(s, feed)
+
+	fmt.Printf("Retention for feed - %s ; posts kept for %d days, at most %d posts (0 keeps forever)\n", feed.Name, policy.days, policy.maxPosts)
+
+	return nil
+}
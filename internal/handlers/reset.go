@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"os"
+	"fmt"
+	"bufio"
+	"context"
+	"strings"
+	"path/filepath"
+	"gator/internal/state"
+	"gator/internal/backup"
+	"gator/internal/config"
+	"gator/internal/database"
+
+	"golang.org/x/term"
+)
+
+// confirm asks the user to type "yes" before a destructive command goes
+// ahead, --yes skips the question for scripts.
+func confirm(question string, yes bool) error {
+	if yes {
+		return nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("Refusing to %s without a terminal to confirm, pass --yes", question)
+	}
+
+	fmt.Printf("This will %s. Type yes to continue: ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != "yes" {
+		return fmt.Errorf("Aborted")
+	}
+
+	return nil
+}
+
+// backupBefore writes a backup of the whole database before a destructive
+// command changes it, restorable with the restore command.
+func backupBefore(ctx context.Context, s *state.State, reason string) error {
+	dir, err := config.BackupDir()
+	if err != nil {
+		return err
+	}
+
+	snap, err := backup.Take(ctx, s.DB)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, reason+"-"+snap.CreatedAt.Format("20060102-150405")+".jsonl.gz")
+	if err := snap.WriteFile(path); err != nil {
+		return err
+	}
+
+	fmt.Println("Backup written to -", path)
+
+	return nil
+}
+
+func handlerResets(s *state.State, cmd Command, user database.User) error {
+	var yes bool
+	var scope, target string
+
+	for i := 0; i < len(cmd.args); i++ {
+		switch cmd.args[i] {
+		case "--yes":
+			yes = true
+		case "--posts":
+			scope = "posts"
+		case "--user", "--feed":
+			if i+1 >= len(cmd.args) {
+				return fmt.Errorf("Expected a value after %s", cmd.args[i])
+			}
+
+			scope = strings.TrimPrefix(cmd.args[i], "--")
+			target = cmd.args[i+1]
+			i++
+		default:
+			return fmt.Errorf("Unknown argument - %s", cmd.args[i])
+		}
+	}
+
+	ctx := context.Background()
+
+	switch scope {
+	case "":
+		if err := confirm("delete every user, feed, follow and post", yes); err != nil {
+			return err
+		}
+
+		if err := backupBefore(ctx, s, "reset"); err != nil {
+			return err
+		}
+
+		if err := s.DB.ResetUsers(ctx); err != nil {
+			return err
+		}
+
+		fmt.Println("Successfully cleaned all tables in the database")
+	case "posts":
+		if err := confirm("delete every post, with its read and starred state", yes); err != nil {
+			return err
+		}
+
+		if err := backupBefore(ctx, s, "reset-posts"); err != nil {
+			return err
+		}
+
+		n, err := s.DB.DeletePosts(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted %d posts, feeds and follows are kept\n", n)
+	case "user":
+		if err := deleteUser(ctx, s, target, yes, "reset-user"); err != nil {
+			return err
+		}
+	case "feed":
+		feed, err := s.DB.GetFeedByURL(ctx, clean_input(target))
+		if err != nil {
+			return err
+		}
+
+		if err := confirm(fmt.Sprintf("delete feed %s with its posts and follows", feed.Name), yes); err != nil {
+			return err
+		}
+
+		if err := backupBefore(ctx, s, "reset-feed"); err != nil {
+			return err
+		}
+
+		if _, err := s.DB.DeleteFeed(ctx, feed.ID); err != nil {
+			return err
+		}
+
+		fmt.Println("Deleted feed -", feed.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+	"errors"
+	"context"
+	"strconv"
+	"strings"
+	"database/sql"
+	"gator/internal/state"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// tagFlag takes --tag <name> out of args.
+func tagFlag(args []string) (string, []string, error) {
+	var tag string
+	var rest []string
+
+	for i := 0; i < len(args); i++ {
+		if args[i] != "--tag" {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		if i+1 >= len(args) {
+			return "", nil, fmt.Errorf("Expected a tag after --tag")
+		}
+
+		tag = tagName(args[i+1])
+		i++
+	}
+
+	return tag, rest, nil
+}
+
+// tagName normalizes a tag, so "#Security" and "security" are the same one.
+func tagName(s string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(clean_input(s)), "#"))
+}
+
+// followedPost parses a post id and checks the post is in a feed the user
+// follows.
+func followedPost(ctx context.Context, s *state.State, user database.User, arg string) (database.GetPostItemsByIDsRow, error) {
+	id, err := strconv.ParseInt(clean_input(arg), 10, 32)
+	if err != nil || id <= 0 {
+		return database.GetPostItemsByIDsRow{}, fmt.Errorf("Expected a post id, got - %s", arg)
+	}
+
+	posts, err := s.DB.GetPostItemsByIDs(ctx, database.GetPostItemsByIDsParams{UserID: user.ID, Ids: []int32{int32(id)}})
+	if err != nil {
+		return database.GetPostItemsByIDsRow{}, err
+	} else if len(posts) == 0 {
+		return database.GetPostItemsByIDsRow{}, fmt.Errorf("No post %d in the feeds you follow", id)
+	}
+
+	return posts[0], nil
+}
+
+// tagPost tags a post for a user, creating the tag on first use.
+func tagPost(ctx context.Context, qtx database.Store, user_id uuid.UUID, post_id int32, name string) error {
+	tag, err := qtx.GetTag(ctx, database.GetTagParams{UserID: user_id, Name: name})
+	if errors.Is(err, sql.ErrNoRows) {
+		c_time := time.Now()
+
+		tag_params := database.CreateTagParams{
+			CreatedAt: c_time,
+			UpdatedAt: c_time,
+			UserID:    user_id,
+			Name:      name,
+		}
+
+		tag, err = qtx.CreateTag(ctx, tag_params)
+	}
+	if err != nil {
+		return err
+	}
+
+	return qtx.TagPost(ctx, database.TagPostParams{PostID: post_id, TagID: tag.ID})
+}
+
+func printPostTags(ctx context.Context, s *state.State, user database.User, post database.GetPostItemsByIDsRow) error {
+	tags, err := s.DB.GetTagsForPost(ctx, database.GetTagsForPostParams{UserID: user.ID, PostID: post.ID})
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		fmt.Printf("Post %d - %s ; has no tags\n", post.ID, sanitize.StripControl(post.Title))
+		return nil
+	}
+
+	fmt.Printf("Post %d - %s ; tags - %s\n", post.ID, sanitize.StripControl(post.Title), strings.Join(tags, ", "))
+
+	return nil
+}
+
+func handlerTag(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected post id and at least one tag")
+	}
+
+	ctx := context.Background()
+
+	post, err := followedPost(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.InTx(ctx, func(qtx database.Store) error {
+		for _, v := range cmd.args[1:] {
+			name := tagName(v)
+			if name == "" {
+				return fmt.Errorf("Expected a tag, got - %s", v)
+			}
+
+			if err := tagPost(ctx, qtx, user.ID, post.ID, name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printPostTags(ctx, s, user, post)
+}
+
+func handlerUntag(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected post id and at least one tag")
+	}
+
+	ctx := context.Background()
+
+	post, err := followedPost(ctx, s, user, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	err = s.DB.InTx(ctx, func(qtx database.Store) error {
+		for _, v := range cmd.args[1:] {
+			name := tagName(v)
+
+			tag, err := qtx.GetTag(ctx, database.GetTagParams{UserID: user.ID, Name: name})
+			if err != nil {
+				return fmt.Errorf("No tag - %s", name)
+			}
+
+			n, err := qtx.UntagPost(ctx, database.UntagPostParams{PostID: post.ID, TagID: tag.ID})
+			if err != nil {
+				return err
+			} else if n == 0 {
+				return fmt.Errorf("Post %d is not tagged - %s", post.ID, name)
+			}
+
+			// A tag exists as long as some post carries it.
+			if err := qtx.DeleteTagIfUnused(ctx, tag.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return printPostTags(ctx, s, user, post)
+}
+
+func handlerTags(s *state.State, cmd Command, user database.User) error {
+	tags, err := s.DB.GetTagsForUser(context.Background(), user.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(tags) == 0 {
+		fmt.Println("No tags yet, label a post with 'tag <post_id> <tag>'")
+		return nil
+	}
+
+	for _, v := range tags {
+		fmt.Printf("* %s (%d posts)\n", v.Name, v.Posts)
+	}
+
+	return nil
+}
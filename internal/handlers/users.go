@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+	"context"
+	"gator/internal/state"
+	"gator/internal/database"
+)
+
+// checkNotLastAdmin refuses changes that would leave nobody able to run the
+// admin commands.
+func checkNotLastAdmin(ctx context.Context, s *state.State, user database.User) error {
+	if user.Role != database.RoleAdmin {
+		return nil
+	}
+
+	admins, err := s.DB.CountAdmins(ctx)
+	if err != nil {
+		return err
+	}
+
+	if admins <= 1 {
+		return fmt.Errorf("%s is the last admin, make another user admin first", user.Name)
+	}
+
+	return nil
+}
+
+// deleteUser removes a user with the feeds they added and their follows,
+// after a confirmation and a backup.
+func deleteUser(ctx context.Context, s *state.State, name string, yes bool, reason string) error {
+	user, err := s.DB.GetUser(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := checkNotLastAdmin(ctx, s, user); err != nil {
+		return err
+	}
+
+	feeds, err := s.DB.GetFeeds(ctx)
+	if err != nil {
+		return err
+	}
+
+	var added int
+	for _, v := range feeds {
+		if v.UserID == user.ID {
+			added++
+		}
+	}
+
+	if err := confirm(fmt.Sprintf("delete user %s with the %d feeds they added and their follows", name, added), yes); err != nil {
+		return err
+	}
+
+	if err := backupBefore(ctx, s, reason); err != nil {
+		return err
+	}
+
+	if _, err := s.DB.DeleteUser(ctx, name); err != nil {
+		return err
+	}
+
+	if s.Cfg.Curr_Username == name {
+		if err := s.Cfg.SetUser(""); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Deleted user - %s ; with %d feeds they added\n", name, added)
+
+	return nil
+}
+
+func handlerDeleteUser(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) == 0 {
+		return fmt.Errorf("Expected username")
+	}
+
+	yes := len(cmd.args) > 1 && cmd.args[1] == "--yes"
+
+	return deleteUser(context.Background(), s, cmd.args[0], yes, "deleteuser")
+}
+
+func handlerRenameUser(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected current and new username")
+	}
+
+	ctx := context.Background()
+
+	target, err := s.DB.GetUser(ctx, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	rename_params := database.RenameUserParams{
+		ID:        target.ID,
+		UpdatedAt: time.Now(),
+		Name:      cmd.args[1],
+	}
+
+	if err := s.DB.RenameUser(ctx, rename_params); err != nil {
+		return err
+	}
+
+	if s.Cfg.Curr_Username == target.Name {
+		if err := s.Cfg.SetUser(rename_params.Name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Renamed user - %s ; to - %s\n", target.Name, rename_params.Name)
+
+	// The password hash is derived from the user name, see fever.APIKey.
+	if target.ApiKey.Valid {
+		fmt.Println("The password was cleared, set it again with the apikey command")
+	}
+
+	return nil
+}
+
+func handlerSetRole(s *state.State, cmd Command, user database.User) error {
+	if len(cmd.args) < 2 {
+		return fmt.Errorf("Expected username and role")
+	}
+
+	role := cmd.args[1]
+	if role != database.RoleAdmin && role != database.RoleMember {
+		return fmt.Errorf("Expected role %s or %s - %s", database.RoleAdmin, database.RoleMember, role)
+	}
+
+	ctx := context.Background()
+
+	target, err := s.DB.GetUser(ctx, cmd.args[0])
+	if err != nil {
+		return err
+	}
+
+	if role == database.RoleMember {
+		if err := checkNotLastAdmin(ctx, s, target); err != nil {
+			return err
+		}
+	}
+
+	role_params := database.SetUserRoleParams{
+		ID:        target.ID,
+		UpdatedAt: time.Now(),
+		Role:      role,
+	}
+
+	if err := s.DB.SetUserRole(ctx, role_params); err != nil {
+		return err
+	}
+
+	fmt.Printf("User - %s ; role set to - %s\n", target.Name, role)
+
+	return nil
+}
@@ -0,0 +1,355 @@
+package migrate
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"context"
+	"io/fs"
+	"database/sql"
+	"gator/sql/schema"
+
+	sqlite_schema "gator/sql/sqlite/schema"
+)
+
+// Runner for the goose-formatted files in sql/schema. Versions are tracked in
+// goose's own goose_db_version table, so databases migrated by hand with goose
+// are picked up as is.
+
+const versionTable = "goose_db_version"
+
+// Dialect holds what differs between the supported database engines.
+type Dialect struct {
+	Schema fs.FS
+
+	tableExists string
+	createTable string
+	insert      string
+	delete      string
+}
+
+var Postgres = Dialect{
+	Schema:      schema.FS,
+	tableExists: "SELECT to_regclass($1) IS NOT NULL",
+	createTable: `CREATE TABLE IF NOT EXISTS ` + versionTable + `(
+	id SERIAL PRIMARY KEY,
+	version_id BIGINT NOT NULL,
+	is_applied BOOLEAN NOT NULL,
+	tstamp TIMESTAMP DEFAULT NOW()
+)`,
+	insert: "INSERT INTO " + versionTable + "(version_id, is_applied) VALUES($1, TRUE)",
+	delete: "DELETE FROM " + versionTable + " WHERE version_id = $1",
+}
+
+var SQLite = Dialect{
+	Schema:      sqlite_schema.FS,
+	tableExists: "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?",
+	createTable: `CREATE TABLE IF NOT EXISTS ` + versionTable + `(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	version_id INTEGER NOT NULL,
+	is_applied BOOLEAN NOT NULL,
+	tstamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`,
+	insert: "INSERT INTO " + versionTable + "(version_id, is_applied) VALUES(?, TRUE)",
+	delete: "DELETE FROM " + versionTable + " WHERE version_id = ?",
+}
+
+// ForDriver returns the dialect of a database/sql driver name.
+func ForDriver(driver string) Dialect {
+	if driver == "sqlite" {
+		return SQLite
+	}
+
+	return Postgres
+}
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration Migration
+	Applied   bool
+}
+
+// Load parses every NNN_name.sql file of the dialect's schema, sorted by
+// version.
+func (d Dialect) Load() ([]Migration, error) {
+	fsys := d.Schema
+
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Migration
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("Migration file name has no version prefix - %s", name)
+		}
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Migration file name has no version prefix - %s", name)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		up, down, err := split(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		res = append(res, Migration{Version: version, Name: name, Up: up, Down: down})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+
+	return res, nil
+}
+
+func split(data string) (string, string, error) {
+	var up, down strings.Builder
+	var curr *strings.Builder
+
+	for _, line := range strings.SplitAfter(data, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			curr = &up
+			continue
+		case "-- +goose Down":
+			curr = &down
+			continue
+		case "-- +goose StatementBegin", "-- +goose StatementEnd":
+			continue
+		}
+
+		if curr != nil {
+			curr.WriteString(line)
+		}
+	}
+
+	if strings.TrimSpace(up.String()) == "" {
+		return "", "", fmt.Errorf("Missing -- +goose Up section")
+	}
+
+	return up.String(), down.String(), nil
+}
+
+// Latest returns the version the given migrations bring a database to.
+func Latest(migs []Migration) int64 {
+	if len(migs) == 0 {
+		return 0
+	}
+
+	return migs[len(migs)-1].Version
+}
+
+// Current returns the newest applied version, 0 for a database that was
+// never migrated.
+func (d Dialect) Current(ctx context.Context, db *sql.DB) (int64, error) {
+	applied, err := d.appliedVersions(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	var res int64
+	for v := range applied {
+		res = max(res, v)
+	}
+
+	return res, nil
+}
+
+func (d Dialect) appliedVersions(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, d.tableExists, versionTable).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	res := map[int64]bool{}
+	if !exists {
+		return res, nil
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version_id, is_applied FROM "+versionTable+" ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// goose keeps a log, the newest row of a version tells its state.
+	seen := map[int64]bool{}
+	for rows.Next() {
+		var version int64
+		var is_applied bool
+		if err := rows.Scan(&version, &is_applied); err != nil {
+			return nil, err
+		}
+
+		if seen[version] {
+			continue
+		}
+		seen[version] = true
+
+		if is_applied && version > 0 {
+			res[version] = true
+		}
+	}
+
+	return res, rows.Err()
+}
+
+func (d Dialect) ensureTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, d.createTable)
+
+	return err
+}
+
+// Up applies every pending migration, each in its own transaction.
+func (d Dialect) Up(ctx context.Context, db *sql.DB, migs []Migration) ([]Migration, error) {
+	if err := d.ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	applied, err := d.appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Migration
+	for _, m := range migs {
+		if applied[m.Version] {
+			continue
+		}
+
+		if err := run(ctx, db, m.Up, d.insert, m.Version); err != nil {
+			return res, fmt.Errorf("%s: %w", m.Name, err)
+		}
+
+		res = append(res, m)
+	}
+
+	return res, nil
+}
+
+// Down rolls back the newest applied migration.
+func (d Dialect) Down(ctx context.Context, db *sql.DB, migs []Migration) (Migration, error) {
+	applied, err := d.appliedVersions(ctx, db)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(migs) - 1; i >= 0; i-- {
+		m := migs[i]
+		if !applied[m.Version] {
+			continue
+		}
+
+		if err := run(ctx, db, m.Down, d.delete, m.Version); err != nil {
+			return m, fmt.Errorf("%s: %w", m.Name, err)
+		}
+
+		return m, nil
+	}
+
+	return Migration{}, fmt.Errorf("No applied migrations to roll back")
+}
+
+func run(ctx context.Context, db *sql.DB, script, record string, version int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d Dialect) List(ctx context.Context, db *sql.DB, migs []Migration) ([]Status, error) {
+	applied, err := d.appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Status, 0, len(migs))
+	for _, m := range migs {
+		res = append(res, Status{Migration: m, Applied: applied[m.Version]})
+	}
+
+	return res, nil
+}
+
+// Check returns a readable error when the database is not at the version
+// this binary was built for, also when a migration below the newest applied
+// one is missing.
+func (d Dialect) Check(ctx context.Context, db *sql.DB, migs []Migration) error {
+	applied, err := d.appliedVersions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("Could not read the database schema version - %w", err)
+	}
+
+	if len(applied) == 0 {
+		return fmt.Errorf("The database has no gator schema yet, run 'gator migrate up' first")
+	}
+
+	known := make(map[int64]bool, len(migs))
+	var missing []string
+	for _, m := range migs {
+		known[m.Version] = true
+
+		if !applied[m.Version] {
+			missing = append(missing, m.Name)
+		}
+	}
+
+	var current int64
+	var unknown []int64
+	for v := range applied {
+		current = max(current, v)
+
+		if !known[v] {
+			unknown = append(unknown, v)
+		}
+	}
+
+	latest := Latest(migs)
+	switch {
+	case len(unknown) > 0:
+		sort.Slice(unknown, func(i, j int) bool { return unknown[i] < unknown[j] })
+		return fmt.Errorf("The database has migrations this gator build doesn't know (%s), update gator", joinVersions(unknown))
+	case current < latest:
+		return fmt.Errorf("The database schema is at version %d but gator needs %d, run 'gator migrate up'", current, latest)
+	case len(missing) > 0:
+		return fmt.Errorf("The database schema is missing migrations %s, run 'gator migrate up'", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func joinVersions(versions []int64) string {
+	res := make([]string, 0, len(versions))
+	for _, v := range versions {
+		res = append(res, strconv.FormatInt(v, 10))
+	}
+
+	return strings.Join(res, ", ")
+}
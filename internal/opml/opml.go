@@ -0,0 +1,55 @@
+package opml
+
+import (
+	"io"
+	"encoding/xml"
+)
+
+// OPML 2.0 (http://opml.org/spec2.opml) subscription lists, the format feed
+// readers import and export. Folders are outlines holding feed outlines.
+
+type Document struct {
+	XMLName     xml.Name  `xml:"opml"`
+	Version     string    `xml:"version,attr"`
+	Title       string    `xml:"head>title"`
+	DateCreated string    `xml:"head>dateCreated,omitempty"`
+	Outlines    []Outline `xml:"body>outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Feed returns the outline of one RSS feed.
+func Feed(name, feed_url, site_url string) Outline {
+	return Outline{Text: name, Title: name, Type: "rss", XMLURL: feed_url, HTMLURL: site_url}
+}
+
+// Folder returns an outline grouping feeds.
+func Folder(name string, feeds []Outline) Outline {
+	return Outline{Text: name, Title: name, Outlines: feeds}
+}
+
+func Write(w io.Writer, doc Document) error {
+	doc.Version = "2.0"
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
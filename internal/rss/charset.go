@@ -0,0 +1,71 @@
+package rss
+
+import (
+	"io"
+	"fmt"
+	"mime"
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Feeds come in whatever encoding their site uses. The encoding is taken, as
+// RFC 7303 orders it, from a byte order mark, then the charset of the
+// Content-Type, then the XML declaration, and the feed is decoded to UTF-8
+// before parsing.
+
+// xmlEncoding finds the encoding of the XML declaration, which is ASCII in
+// every encoding a declaration can be read in without a BOM.
+var xmlEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+var boms = []struct {
+	bom []byte
+	enc encoding.Encoding
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, unicode.UTF8},
+	{[]byte{0xFE, 0xFF}, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)},
+	{[]byte{0xFF, 0xFE}, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)},
+}
+
+// toUTF8 decodes body to UTF-8, content_type is the Content-Type header of the
+// response, if any.
+func toUTF8(body []byte, content_type string) ([]byte, error) {
+	for _, v := range boms {
+		if rest, ok := bytes.CutPrefix(body, v.bom); ok {
+			return v.enc.NewDecoder().Bytes(rest)
+		}
+	}
+
+	label := ""
+	if _, params, err := mime.ParseMediaType(content_type); err == nil {
+		label = params["charset"]
+	}
+
+	if label == "" {
+		if m := xmlEncoding.FindSubmatch(body[:min(len(body), 1024)]); m != nil {
+			label = string(m[1])
+		}
+	}
+
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" || label == "utf-8" || label == "utf8" {
+		return body, nil
+	}
+
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown charset of the feed - %s", label)
+	}
+
+	return enc.NewDecoder().Bytes(body)
+}
+
+// decodedCharset is the CharsetReader of feeds toUTF8 already decoded, it
+// only keeps the XML declaration from failing the parse.
+func decodedCharset(_ string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
@@ -0,0 +1,120 @@
+package rss
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+func encode(t *testing.T, enc encoding.Encoding, s string) []byte {
+	t.Helper()
+
+	res, err := enc.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestToUTF8(t *testing.T) {
+	const (
+		russian       = "<title>Привет, мир</title>"
+		japanese_text = "<title>こんにちは世界</title>"
+	)
+
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+
+	tests := []struct {
+		name         string
+		body         []byte
+		content_type string
+		want         string
+	}{
+		{"UTF-8 without a label", []byte(russian), "", russian},
+		{"UTF-8 by Content-Type", []byte(russian), "application/rss+xml; charset=UTF-8", russian},
+		{"windows-1251 by Content-Type", encode(t, charmap.Windows1251, russian), "text/xml; charset=windows-1251", russian},
+		{"windows-1251 by declaration", encode(t, charmap.Windows1251, `<?xml version="1.0" encoding="windows-1251"?>`+russian), "text/xml", `<?xml version="1.0" encoding="windows-1251"?>` + russian},
+		{"Shift_JIS by declaration", encode(t, japanese.ShiftJIS, `<?xml version='1.0' encoding='Shift_JIS'?>`+japanese_text), "", `<?xml version='1.0' encoding='Shift_JIS'?>` + japanese_text},
+		{"Shift_JIS by Content-Type", encode(t, japanese.ShiftJIS, japanese_text), `text/xml; charset="shift_jis"`, japanese_text},
+		{"Content-Type over declaration", encode(t, charmap.Windows1251, `<?xml version="1.0" encoding="ISO-8859-1"?>`+russian), "text/xml; charset=windows-1251", `<?xml version="1.0" encoding="ISO-8859-1"?>` + russian},
+		{"UTF-8 BOM over Content-Type", append([]byte{0xEF, 0xBB, 0xBF}, russian...), "text/xml; charset=windows-1251", russian},
+		{"UTF-16 BOM over declaration", encode(t, utf16, `<?xml version="1.0" encoding="windows-1251"?>`+russian), "", `<?xml version="1.0" encoding="windows-1251"?>` + russian},
+		{"unparsable Content-Type", encode(t, charmap.Windows1251, `<?xml version="1.0" encoding="windows-1251"?>`+russian), "text/xml; charset", `<?xml version="1.0" encoding="windows-1251"?>` + russian},
+	}
+
+	for _, v := range tests {
+		got, err := toUTF8(v.body, v.content_type)
+		if err != nil {
+			t.Errorf("%s: toUTF8 = %v", v.name, err)
+			continue
+		}
+
+		if string(got) != v.want {
+			t.Errorf("%s: toUTF8 = %q, want %q", v.name, got, v.want)
+		}
+	}
+}
+
+func TestToUTF8UnknownCharset(t *testing.T) {
+	tests := []struct {
+		body         string
+		content_type string
+	}{
+		{russianFeed, "text/xml; charset=klingon"},
+		{`<?xml version="1.0" encoding="x-unknown"?><rss/>`, ""},
+	}
+
+	for _, v := range tests {
+		if _, err := toUTF8([]byte(v.body), v.content_type); err == nil {
+			t.Errorf("toUTF8(%q, %q) = nil error, want an unknown charset", v.body, v.content_type)
+		}
+	}
+}
+
+const russianFeed = `<?xml version="1.0" encoding="windows-1251"?>
+<rss version="2.0"><channel><title>Новости</title>
+<item><title>Первая новость</title><link>https://example.ru/1</link></item>
+</channel></rss>`
+
+const japaneseFeed = `<?xml version="1.0" encoding="Shift_JIS"?>
+<rss version="2.0"><channel><title>ニュース</title>
+<item><title>最初の記事</title><link>https://example.jp/1</link></item>
+</channel></rss>`
+
+// The declaration still names the original encoding after toUTF8, parseFeed
+// must not decode twice.
+func TestParseEncodedFeed(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  []byte
+		title string
+		item  string
+	}{
+		{"windows-1251", encode(t, charmap.Windows1251, russianFeed), "Новости", "Первая новость"},
+		{"Shift_JIS", encode(t, japanese.ShiftJIS, japaneseFeed), "ニュース", "最初の記事"},
+	}
+
+	for _, v := range tests {
+		body, err := toUTF8(v.body, "application/rss+xml")
+		if err != nil {
+			t.Fatalf("%s: toUTF8 = %v", v.name, err)
+		}
+
+		feed, err := parseFeed(body)
+		if err != nil {
+			t.Fatalf("%s: parseFeed = %v", v.name, err)
+		}
+
+		if feed.Channel.Title != v.title || len(feed.Channel.Item) != 1 || feed.Channel.Item[0].Title != v.item {
+			t.Errorf("%s: parsed %q with items %+v, want %q with %q", v.name, feed.Channel.Title, feed.Channel.Item, v.title, v.item)
+		}
+
+		if len(feed.Warnings) != 0 {
+			t.Errorf("%s: warnings %q, want none", v.name, feed.Warnings)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package rss
+
+import (
+	"fmt"
+	"bytes"
+	"regexp"
+	"unicode/utf8"
+	"encoding/xml"
+)
+
+// Feeds in the wild are often not well-formed XML: HTML entities like &nbsp;
+// that XML doesn't define, control characters pasted from word processors,
+// bare ampersands in titles. A feed the strict parser rejects is cleaned up
+// and parsed again the way browsers read HTML, and when even that fails every
+// item is parsed on its own, so one broken item doesn't lose the others. What
+// had to be repaired or dropped is kept in Warnings.
+
+var (
+	cdataStart = []byte("<![CDATA[")
+	cdataEnd   = []byte("]]>")
+
+	// entityRef is a well-formed reference, any other & is taken literally.
+	entityRef = regexp.MustCompile(`^&(#[0-9]+|#[xX][0-9a-fA-F]+|[A-Za-z][A-Za-z0-9]*);`)
+
+	itemStart = regexp.MustCompile(`<item[\s>/]`)
+	itemBlock = regexp.MustCompile(`(?s)<item[\s>].*?</item\s*>`)
+)
+
+// autoClose are the HTML void elements, closed without an end tag, but <link>
+// which feeds use for URLs.
+var autoClose = func() []string {
+	var res []string
+	for _, v := range xml.HTMLAutoClose {
+		if v != "link" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}()
+
+// parseFeed parses a UTF-8 feed, falling back to the lenient parser.
+func parseFeed(body []byte) (*RSSFeed, error) {
+	var res RSSFeed
+
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.CharsetReader = decodedCharset
+
+	strict_err := dec.Decode(&res)
+	if strict_err == nil {
+		return &res, nil
+	}
+
+	return parseLenient(body, strict_err)
+}
+
+func lenientDecoder(data []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = decodedCharset
+	dec.Strict = false
+	dec.AutoClose = autoClose
+	dec.Entity = xml.HTMLEntity
+
+	return dec
+}
+
+func parseLenient(body []byte, strict_err error) (*RSSFeed, error) {
+	cleaned, warnings := cleanXML(body)
+	warnings = append([]string{fmt.Sprintf("Not well-formed XML, parsed leniently - %v", strict_err)}, warnings...)
+
+	var res RSSFeed
+	if err := lenientDecoder(cleaned).Decode(&res); err == nil {
+		res.Warnings = warnings
+		return &res, nil
+	}
+
+	// Salvage the channel from what comes before the first item, and every
+	// item that parses by itself.
+	res = RSSFeed{}
+
+	head := cleaned
+	if loc := itemStart.FindIndex(cleaned); loc != nil {
+		head = cleaned[:loc[0]]
+	}
+
+	// The decoder fills in fields up to the error, a truncated head still
+	// gives the title and link.
+	if err := lenientDecoder(head).Decode(&res); err != nil && res.Channel.Title == "" && res.Channel.Link == "" {
+		warnings = append(warnings, fmt.Sprintf("Couldn't read the channel - %v", err))
+	}
+
+	blocks := itemBlock.FindAll(cleaned, -1)
+	for i, v := range blocks {
+		var item RSSItem
+		if err := lenientDecoder(v).Decode(&item); err != nil {
+			warnings = append(warnings, fmt.Sprintf("Dropped item %d - %v", i+1, err))
+			continue
+		}
+
+		res.Channel.Item = append(res.Channel.Item, item)
+	}
+
+	if unclosed := len(itemStart.FindAll(cleaned, -1)) - len(blocks); unclosed > 0 {
+		warnings = append(warnings, fmt.Sprintf("Dropped %d items that are never closed", unclosed))
+	}
+
+	if len(res.Channel.Item) == 0 && res.Channel.Title == "" {
+		return &RSSFeed{}, strict_err
+	}
+
+	res.Warnings = warnings
+
+	return &res, nil
+}
+
+// cleanXML makes body closer to well-formed: invalid UTF-8 is replaced,
+// characters XML forbids are removed and bare ampersands outside CDATA
+// sections are escaped.
+func cleanXML(body []byte) ([]byte, []string) {
+	var b bytes.Buffer
+	b.Grow(len(body))
+
+	var bad_utf8, bad_chars, bare_amps int
+
+	in_cdata := false
+	for i := 0; i < len(body); {
+		switch {
+		case !in_cdata && bytes.HasPrefix(body[i:], cdataStart):
+			in_cdata = true
+			b.Write(cdataStart)
+			i += len(cdataStart)
+			continue
+		case in_cdata && bytes.HasPrefix(body[i:], cdataEnd):
+			in_cdata = false
+			b.Write(cdataEnd)
+			i += len(cdataEnd)
+			continue
+		case !in_cdata && body[i] == '&' && !entityRef.Match(body[i:min(len(body), i+40)]):
+			bare_amps++
+			b.WriteString("&amp;")
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRune(body[i:])
+		i += size
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			bad_utf8++
+			b.WriteRune(utf8.RuneError)
+		case !validChar(r):
+			bad_chars++
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	var warnings []string
+
+	if bad_utf8 > 0 {
+		warnings = append(warnings, fmt.Sprintf("Replaced %d invalid UTF-8 sequences", bad_utf8))
+	}
+
+	if bad_chars > 0 {
+		warnings = append(warnings, fmt.Sprintf("Removed %d characters not allowed in XML", bad_chars))
+	}
+
+	if bare_amps > 0 {
+		warnings = append(warnings, fmt.Sprintf("Escaped %d bare ampersands", bare_amps))
+	}
+
+	return b.Bytes(), warnings
+}
+
+// validChar reports whether XML 1.0 allows r in a document.
+func validChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		(r >= 0x20 && r <= 0xD7FF) ||
+		(r >= 0xE000 && r <= 0xFFFD) ||
+		(r >= 0x10000 && r <= 0x10FFFF)
+}
@@ -0,0 +1,126 @@
+package rss
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCleanXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		want     string
+		warnings []string
+	}{
+		{"well-formed", `<title>Tom &amp; Jerry &#38; &#x26;</title>`, `<title>Tom &amp; Jerry &#38; &#x26;</title>`, nil},
+		{"HTML entity", `<title>a&nbsp;b</title>`, `<title>a&nbsp;b</title>`, nil},
+		{"bare ampersands", `<title>Tom & Jerry &c. &#;</title>`, `<title>Tom &amp; Jerry &amp;c. &amp;#;</title>`, []string{"Escaped 3 bare ampersands"}},
+		{"CDATA", `<d><![CDATA[<b>a & b</b>]]> & c</d>`, `<d><![CDATA[<b>a & b</b>]]> &amp; c</d>`, []string{"Escaped 1 bare ampersands"}},
+		{"unclosed CDATA", `<d><![CDATA[a & b</d>`, `<d><![CDATA[a & b</d>`, nil},
+		{"control characters", "<title>a\x00b\x08c\x1Bd\tf</title>", "<title>abcd\tf</title>", []string{"Removed 3 characters not allowed in XML"}},
+		{"invalid UTF-8", "<title>a\xffb\xc3</title>", "<title>a�b�</title>", []string{"Replaced 2 invalid UTF-8 sequences"}},
+		{"everything", "<t>\xff\x01&</t>", "<t>�&amp;</t>", []string{"Replaced 1 invalid UTF-8 sequences", "Removed 1 characters not allowed in XML", "Escaped 1 bare ampersands"}},
+	}
+
+	for _, v := range tests {
+		got, warnings := cleanXML([]byte(v.body))
+		if string(got) != v.want {
+			t.Errorf("%s: cleanXML(%q) = %q, want %q", v.name, v.body, got, v.want)
+		}
+
+		if !slices.Equal(warnings, v.warnings) {
+			t.Errorf("%s: cleanXML(%q) warnings = %q, want %q", v.name, v.body, warnings, v.warnings)
+		}
+	}
+}
+
+func feedXML(items ...string) string {
+	return `<?xml version="1.0"?><rss version="2.0"><channel><title>News &amp; more</title><link>https://example.com/</link>` +
+		strings.Join(items, "") + `</channel></rss>`
+}
+
+func TestParseFeed(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		title    string
+		items    []string
+		warnings []string
+	}{
+		{
+			name:  "well-formed",
+			body:  feedXML(`<item><title>One</title></item>`, `<item><title>Two</title></item>`),
+			title: "News & more",
+			items: []string{"One", "Two"},
+		},
+		{
+			name:     "HTML entity and bare ampersand",
+			body:     feedXML(`<item><title>Tom & Jerry</title></item>`, `<item><title>a&nbsp;b</title></item>`),
+			title:    "News & more",
+			items:    []string{"Tom & Jerry", "a\u00a0b"},
+			warnings: []string{"Not well-formed XML", "Escaped 1 bare ampersands"},
+		},
+		{
+			name:     "one broken item",
+			body:     feedXML(`<item><title>One</title></item>`, `<item><title>Two <<</title></item>`, `<item><title>Three</title></item>`),
+			title:    "News & more",
+			items:    []string{"One", "Three"},
+			warnings: []string{"Not well-formed XML", "Dropped item 2 - "},
+		},
+		{
+			name:     "unclosed item",
+			body:     strings.TrimSuffix(feedXML(`<item><title>One</title></item>`, `<item><title>Two <<</title>`), `</channel></rss>`),
+			title:    "News & more",
+			items:    []string{"One"},
+			warnings: []string{"Not well-formed XML", "Dropped 1 items that are never closed"},
+		},
+		{
+			name:     "broken channel",
+			body:     `<rss><channel><title>Cut <</title><item><title>One</title></item><item><title>Two</title></item>`,
+			items:    []string{"One", "Two"},
+			warnings: []string{"Not well-formed XML", "Couldn't read the channel - "},
+		},
+	}
+
+	for _, v := range tests {
+		feed, err := parseFeed([]byte(v.body))
+		if err != nil {
+			t.Errorf("%s: parseFeed = %v", v.name, err)
+			continue
+		}
+
+		if feed.Channel.Title != v.title {
+			t.Errorf("%s: title %q, want %q", v.name, feed.Channel.Title, v.title)
+		}
+
+		var items []string
+		for _, item := range feed.Channel.Item {
+			items = append(items, item.Title)
+		}
+
+		if !slices.Equal(items, v.items) {
+			t.Errorf("%s: items %q, want %q", v.name, items, v.items)
+		}
+
+		// Warnings quote parser errors, only their start is compared.
+		if len(feed.Warnings) != len(v.warnings) {
+			t.Errorf("%s: warnings %q, want %q", v.name, feed.Warnings, v.warnings)
+			continue
+		}
+
+		for i, w := range v.warnings {
+			if !strings.HasPrefix(feed.Warnings[i], w) {
+				t.Errorf("%s: warning %d is %q, want it to start with %q", v.name, i, feed.Warnings[i], w)
+			}
+		}
+	}
+}
+
+func TestParseFeedUnreadable(t *testing.T) {
+	for _, v := range []string{"", "not a feed", "<html><body><p>A page</p>", "<rss><channel><item><title>a <<"} {
+		if feed, err := parseFeed([]byte(v)); err == nil && (feed.Channel.Title != "" || len(feed.Channel.Item) > 0) {
+			t.Errorf("parseFeed(%q) = %+v, want an error or an empty feed", v, feed)
+		}
+	}
+}
@@ -1,21 +1,37 @@
 package rss
 
 import (
-	"io"
 	"fmt"
 	"html"
+	"bytes"
+	"regexp"
 	"context"
-	"net/http"
-	"encoding/xml"
+	"strings"
+	"net/url"
+	"gator/internal/fetch"
 )
 
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title           string    `xml:"title"`
+		Link            string    `xml:"link"`
+		Description     string    `xml:"description"`
+		Language        string    `xml:"language"`
+		Generator       string    `xml:"generator"`
+		ImageURL        string    `xml:"image>url"`
+		// The scheduling hints are numbers, read as text so a malformed one
+		// is ignored instead of failing the whole feed.
+		TTL             string    `xml:"ttl"`
+		SkipHours       []string  `xml:"skipHours>hour"`
+		SkipDays        []string  `xml:"skipDays>day"`
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		Item            []RSSItem `xml:"item"`
 	} `xml:"channel"`
+
+	// Warnings tell what the lenient parser repaired or dropped, empty for
+	// well-formed feeds.
+	Warnings []string `xml:"-"`
 }
 
 type RSSItem struct {
@@ -35,33 +51,85 @@ func (o *RSSFeed) clean_feed() {
 	}
 }
 
-func FetchFeed(ctx *context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(*ctx, http.MethodGet, feedURL, nil)
+func FetchFeed(ctx context.Context, fetcher *fetch.Fetcher, feedURL string) (*RSSFeed, error) {
+	resp, err := fetcher.Get(ctx, feedURL, "application/rss+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
 	if err != nil {
 		return &RSSFeed{}, err
 	}
 
-	req.Header.Set("User-Agent", "gator")
-
-	resp, err := http.DefaultClient.Do(req)
+	body, err := toUTF8(resp.Body, resp.Header.Get("Content-Type"))
 	if err != nil {
 		return &RSSFeed{}, err
-	} else if resp.StatusCode > 299 {
-		return &RSSFeed{}, fmt.Errorf("Response Status Code was not 200-: %d", resp.StatusCode)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	res, err := parseFeed(body)
 	if err != nil {
 		return &RSSFeed{}, err
 	}
-	
-	var res RSSFeed
-	if err := xml.Unmarshal(body, &res); err != nil {
-		return &RSSFeed{}, err
-	}
 
 	res.clean_feed()
 
-	return &res, nil
+	return res, nil
+}
+
+// SiteImage returns the channel image, falling back to the favicon of the
+// site the channel links to.
+func (o *RSSFeed) SiteImage() string {
+	if o.Channel.ImageURL != "" {
+		return strings.TrimSpace(o.Channel.ImageURL)
+	}
+
+	site, err := url.Parse(strings.TrimSpace(o.Channel.Link))
+	if err != nil || site.Host == "" {
+		return ""
+	}
+
+	return site.Scheme + "://" + site.Host + "/favicon.ico"
+}
+
+var (
+	linkTag  = regexp.MustCompile(`(?is)<link\s[^>]*>`)
+	linkAttr = regexp.MustCompile(`(?is)(rel|type|href)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// DiscoverFeed returns the feed URL behind pageURL. A URL that already serves a
+// feed is returned as is, otherwise the page is searched for an RSS
+// <link rel="alternate">, the only kind gator reads, and its href is resolved
+// against where the page came from after redirects.
+func DiscoverFeed(ctx context.Context, fetcher *fetch.Fetcher, pageURL string) (string, error) {
+	resp, err := fetcher.Get(ctx, pageURL, "")
+	if err != nil {
+		return "", err
+	}
+
+	body := resp.Body
+
+	trimmed := bytes.TrimSpace(body)
+	if strings.Contains(resp.Header.Get("Content-Type"), "xml") || bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<rss")) {
+		return pageURL, nil
+	}
+
+	for _, tag := range linkTag.FindAll(body, -1) {
+		attrs := map[string]string{}
+		for _, m := range linkAttr.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(m[1]))] = string(m[2]) + string(m[3])
+		}
+
+		if !strings.Contains(strings.ToLower(attrs["rel"]), "alternate") || attrs["href"] == "" {
+			continue
+		}
+
+		if strings.ToLower(strings.TrimSpace(attrs["type"])) != "application/rss+xml" {
+			continue
+		}
+
+		ref, err := url.Parse(html.UnescapeString(attrs["href"]))
+		if err != nil {
+			return "", err
+		}
+
+		return resp.URL.ResolveReference(ref).String(), nil
+	}
+
+	return "", fmt.Errorf("No feed found on page - %s", pageURL)
 }
@@ -0,0 +1,183 @@
+package rss
+
+import (
+	"sort"
+	"time"
+	"strconv"
+	"strings"
+)
+
+// Schedule bounds how often a single feed may be fetched.
+type Schedule struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// cadenceSamples is how many of the newest posts are used to estimate how
+// often a feed publishes.
+const cadenceSamples = 10
+
+var syPeriods = map[string]time.Duration{
+	"hourly":  time.Hour,
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 30 * 24 * time.Hour,
+	"yearly":  365 * 24 * time.Hour,
+}
+
+// pubDateLayouts are tried in order, RFC 822 dates with a numeric zone first
+// since that is what the RSS spec asks for. A day of "2" takes one or two
+// digits, the weekday is optional in RFC 822 and Atom-minded feeds use RFC 3339.
+var pubDateLayouts = []string{
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	time.RFC3339,
+}
+
+// rfc822Zones are the zone names of RFC 822 in seconds east of UTC. time.Parse
+// only knows the offsets of the local zone's names, it gives the others 0.
+var rfc822Zones = map[string]int{
+	"UT":  0,
+	"UTC": 0,
+	"GMT": 0,
+	"EST": -5 * 60 * 60,
+	"EDT": -4 * 60 * 60,
+	"CST": -6 * 60 * 60,
+	"CDT": -5 * 60 * 60,
+	"MST": -7 * 60 * 60,
+	"MDT": -6 * 60 * 60,
+	"PST": -8 * 60 * 60,
+	"PDT": -7 * 60 * 60,
+}
+
+// ParsePubDate parses the item dates found in the wild, see pubDateLayouts.
+// Zones are numeric or the names of RFC 822, any other name is taken as UTC.
+func ParsePubDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	// Go reads zone names of three letters or more, UT is RFC 822's UTC.
+	if rest, ok := strings.CutSuffix(s, " UT"); ok {
+		s = rest + " UTC"
+	}
+
+	var first_err error
+	for _, v := range pubDateLayouts {
+		t, err := time.Parse(v, s)
+		if err == nil {
+			if name, _ := t.Zone(); rfc822Zones[name] != 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, rfc822Zones[name]))
+			}
+
+			return t, nil
+		}
+
+		if first_err == nil {
+			first_err = err
+		}
+	}
+
+	return time.Time{}, first_err
+}
+
+// parseCount reads a number of a scheduling hint, anything else is 0.
+func parseCount(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
+
+// NextFetch returns when the feed should be fetched again. The interval is the
+// average gap between the newest posts, never shorter than the channel's <ttl>
+// or sy:updatePeriod/sy:updateFrequency hints, clamped to sched, and moved out
+// of any <skipHours>/<skipDays> window.
+func (o *RSSFeed) NextFetch(now time.Time, sched Schedule) time.Time {
+	interval := sched.Max
+
+	var dates []time.Time
+	for _, v := range o.Channel.Item {
+		if t, err := ParsePubDate(v.PubDate); err == nil {
+			dates = append(dates, t)
+		}
+	}
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].After(dates[j]) })
+	if len(dates) > cadenceSamples {
+		dates = dates[:cadenceSamples]
+	}
+
+	if len(dates) > 1 {
+		interval = dates[0].Sub(dates[len(dates)-1]) / time.Duration(len(dates)-1)
+
+		// A feed that went quiet should not keep the pace of its last burst.
+		if since := now.Sub(dates[0]); since > interval {
+			interval = since
+		}
+	}
+
+	if ttl := time.Duration(parseCount(o.Channel.TTL)) * time.Minute; ttl > interval {
+		interval = ttl
+	}
+
+	if period, ok := syPeriods[strings.ToLower(strings.TrimSpace(o.Channel.UpdatePeriod))]; ok {
+		freq := parseCount(o.Channel.UpdateFrequency)
+		if freq < 1 {
+			freq = 1
+		}
+
+		if hint := period / time.Duration(freq); hint > interval {
+			interval = hint
+		}
+	}
+
+	if interval < sched.Min {
+		interval = sched.Min
+	}
+
+	if sched.Max > 0 && interval > sched.Max {
+		interval = sched.Max
+	}
+
+	return o.skip(now.Add(interval))
+}
+
+// skip moves t forward to the first hour that is not excluded by <skipHours>
+// or <skipDays>, both of which are in GMT per the RSS spec.
+func (o *RSSFeed) skip(t time.Time) time.Time {
+	if len(o.Channel.SkipHours) == 0 && len(o.Channel.SkipDays) == 0 {
+		return t
+	}
+
+	hours := make(map[int]bool, len(o.Channel.SkipHours))
+	for _, v := range o.Channel.SkipHours {
+		// Hours that aren't numbers are ignored, 24 is midnight too.
+		if hour, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && hour >= 0 {
+			hours[hour%24] = true
+		}
+	}
+
+	days := make(map[string]bool, len(o.Channel.SkipDays))
+	for _, v := range o.Channel.SkipDays {
+		days[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+
+	// A week of hours covers every combination, anything left is a feed that
+	// skips everything and is fetched at t anyway.
+	c := t.UTC()
+	for i := 0; i < 7*24; i++ {
+		if !hours[c.Hour()] && !days[strings.ToLower(c.Weekday().String())] {
+			if i == 0 {
+				return t
+			}
+			return c
+		}
+
+		c = c.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return t
+}
@@ -0,0 +1,48 @@
+package rss
+
+import (
+	"time"
+	"testing"
+)
+
+func TestParsePubDate(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"Mon, 02 Jan 2006 15:04:05 -0700", "2006-01-02T22:04:05Z"},
+		{"Mon, 2 Jan 2006 15:04:05 +0100", "2006-01-02T14:04:05Z"},
+		{"2 Jan 2006 15:04:05 -0700", "2006-01-02T22:04:05Z"},
+		{"  Mon, 02 Jan 2006 15:04:05 GMT  ", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 UT", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 UTC", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 EST", "2006-01-02T20:04:05Z"},
+		{"Sun, 2 Jul 2006 15:04:05 EDT", "2006-07-02T19:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 CST", "2006-01-02T21:04:05Z"},
+		{"Sun, 02 Jul 2006 15:04:05 CDT", "2006-07-02T20:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 MST", "2006-01-02T22:04:05Z"},
+		{"Sun, 02 Jul 2006 15:04:05 MDT", "2006-07-02T21:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 PST", "2006-01-02T23:04:05Z"},
+		{"Sun, 02 Jul 2006 15:04:05 PDT", "2006-07-02T22:04:05Z"},
+		{"2006-01-02T15:04:05+02:00", "2006-01-02T13:04:05Z"},
+		{"2006-01-02T15:04:05Z", "2006-01-02T15:04:05Z"},
+	}
+
+	for _, v := range tests {
+		got, err := ParsePubDate(v.s)
+		if err != nil {
+			t.Errorf("ParsePubDate(%q) = %v", v.s, err)
+			continue
+		}
+
+		if got := got.UTC().Format(time.RFC3339); got != v.want {
+			t.Errorf("ParsePubDate(%q) = %s, want %s", v.s, got, v.want)
+		}
+	}
+
+	for _, v := range []string{"", "yesterday", "2006-01-02", "Mon, 32 Jan 2006 15:04:05 GMT"} {
+		if _, err := ParsePubDate(v); err == nil {
+			t.Errorf("ParsePubDate(%q) = nil error, want an error", v)
+		}
+	}
+}
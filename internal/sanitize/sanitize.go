@@ -0,0 +1,242 @@
+package sanitize
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Post bodies come from whoever publishes a feed. HTML keeps the markup of an
+// allow-list, enough for formatted text, links, images and tables, and drops
+// everything able to run code or restyle the page; Text renders the same body
+// for a terminal.
+
+// allowed maps every kept element to the attributes it keeps. Other elements
+// are unwrapped, their content stays.
+var allowed = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.B:          nil,
+	atom.Blockquote: nil,
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.Q:          nil,
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// dropped elements are removed with their content.
+var dropped = map[atom.Atom]bool{
+	atom.Applet:   true,
+	atom.Base:     true,
+	atom.Button:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Frame:    true,
+	atom.Frameset: true,
+	atom.Head:     true,
+	atom.Iframe:   true,
+	atom.Input:    true,
+	atom.Link:     true,
+	atom.Math:     true,
+	atom.Meta:     true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+}
+
+// parse reads body as the content of a <div>, the way feeds embed it.
+func parse(body string) []*html.Node {
+	nodes, err := html.ParseFragment(strings.NewReader(body), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		// Only fails on read errors, impossible with a string.
+		return []*html.Node{{Type: html.TextNode, Data: body}}
+	}
+
+	return nodes
+}
+
+// HTML returns body with only allow-listed elements and attributes. Relative
+// links and images are resolved against base, the URL of the post, and only
+// http, https and mailto links are kept.
+func HTML(body, base string) string {
+	base_url, err := url.Parse(base)
+	if err != nil || base == "" {
+		base_url = nil
+	}
+
+	var b strings.Builder
+	for _, v := range parse(body) {
+		writeHTML(&b, v, base_url)
+	}
+
+	return b.String()
+}
+
+func writeHTML(b *strings.Builder, n *html.Node, base *url.URL) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		// Comments and doctypes.
+		return
+	}
+
+	if dropped[n.DataAtom] {
+		return
+	}
+
+	attrs, ok := allowed[n.DataAtom]
+	if !ok {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeHTML(b, c, base)
+		}
+		return
+	}
+
+	var kept []html.Attribute
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !contains(attrs, a.Key) {
+			continue
+		}
+
+		if a.Key == "href" || a.Key == "src" {
+			link, ok := cleanURL(a.Val, base, a.Key == "href")
+			if !ok {
+				continue
+			}
+			a.Val = link
+		}
+
+		kept = append(kept, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+
+	if n.DataAtom == atom.Img && !hasAttr(kept, "src") {
+		return
+	}
+
+	if n.DataAtom == atom.A && hasAttr(kept, "href") {
+		kept = append(kept, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+	}
+
+	b.WriteString("<" + n.Data)
+	for _, a := range kept {
+		b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	b.WriteString(">")
+
+	if n.DataAtom == atom.Br || n.DataAtom == atom.Hr || n.DataAtom == atom.Img {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeHTML(b, c, base)
+	}
+
+	b.WriteString("</" + n.Data + ">")
+}
+
+// cleanURL resolves link against base and reports whether it is safe to keep,
+// mailto is only allowed for links.
+func cleanURL(link string, base *url.URL, is_link bool) (string, bool) {
+	ref, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return "", false
+	}
+
+	if base != nil {
+		ref = base.ResolveReference(ref)
+	}
+
+	switch strings.ToLower(ref.Scheme) {
+	case "http", "https":
+	case "mailto":
+		if !is_link {
+			return "", false
+		}
+	case "":
+		// Relative, without a base to resolve it against.
+		if ref.Opaque != "" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	return ref.String(), true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasAttr(attrs []html.Attribute, key string) bool {
+	for _, v := range attrs {
+		if v.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, v := range n.Attr {
+		if v.Key == key && v.Namespace == "" {
+			return v.Val
+		}
+	}
+
+	return ""
+}
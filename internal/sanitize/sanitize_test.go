@@ -0,0 +1,113 @@
+package sanitize
+
+import (
+	"strings"
+	"testing"
+)
+
+const testBase = "https://example.com/posts/1"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"formatting", `<p>Hello <b>bold</b> <em>world</em></p>`, `<p>Hello <b>bold</b> <em>world</em></p>`},
+		{"script", `<p>a</p><script>alert(1)</script>`, `<p>a</p>`},
+		{"style", `<style>body{display:none}</style>text`, `text`},
+		{"iframe", `<iframe src="https://evil.example"></iframe>ok`, `ok`},
+		{"svg", `<svg onload="alert(1)"><circle/></svg>ok`, `ok`},
+		{"form", `<form action="/x"><input name="q"></form>ok`, `ok`},
+		{"event handlers", `<p onclick="alert(1)" style="color:red" class="x">a</p>`, `<p>a</p>`},
+		{"unknown element unwrapped", `<div><span>a</span></div>`, `a`},
+		{"comment", `a<!-- hidden -->b`, `ab`},
+		{"link", `<a href="https://example.org/" title="t">x</a>`, `<a href="https://example.org/" title="t" rel="nofollow noopener noreferrer">x</a>`},
+		{"relative link", `<a href="../about">x</a>`, `<a href="https://example.com/about" rel="nofollow noopener noreferrer">x</a>`},
+		{"mailto link", `<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com" rel="nofollow noopener noreferrer">x</a>`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"javascript link with spaces", `<a href=" JavaScript:alert(1)">x</a>`, `<a>x</a>`},
+		{"data link", `<a href="data:text/html,<script>alert(1)</script>">x</a>`, `<a>x</a>`},
+		{"relative image", `<img src="/img.png" alt="a" onerror="alert(1)">`, `<img src="https://example.com/img.png" alt="a">`},
+		{"mailto image", `<img src="mailto:a@example.com">`, ``},
+		{"javascript image", `<img src="javascript:alert(1)">`, ``},
+		{"escaped text", `a &lt;script&gt; &amp; b`, `a &lt;script&gt; &amp; b`},
+		{"escaped attribute", `<a href="https://example.org/?a=1&amp;b=&quot;2&quot;">x</a>`, `<a href="https://example.org/?a=1&amp;b=&#34;2&#34;" rel="nofollow noopener noreferrer">x</a>`},
+		{"table", `<table><tr><td colspan="2" width="5">a</td></tr></table>`, `<table><tbody><tr><td colspan="2">a</td></tr></tbody></table>`},
+	}
+
+	for _, v := range tests {
+		if got := HTML(v.body, testBase); got != v.want {
+			t.Errorf("%s: HTML(%q) = %q, want %q", v.name, v.body, got, v.want)
+		}
+	}
+}
+
+func TestHTMLWithoutBase(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`<a href="/about">x</a>`, `<a href="/about" rel="nofollow noopener noreferrer">x</a>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+	}
+
+	for _, v := range tests {
+		if got := HTML(v.body, ""); got != v.want {
+			t.Errorf("HTML(%q) = %q, want %q", v.body, got, v.want)
+		}
+	}
+}
+
+func TestStripControl(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"line\nand\ttab", "line\nand\ttab"},
+		{"\x1b[2J\x1b]0;title\x07clear", "[2J]0;titleclear"},
+		{"nul\x00 del\x7f", "nul del"},
+		{"c1 \u009b31m csi", "c1 31m csi"},
+		{"carriage\rreturn", "carriagereturn"},
+		{"unicode é ✓", "unicode é ✓"},
+	}
+
+	for _, v := range tests {
+		if got := StripControl(v.s); got != v.want {
+			t.Errorf("StripControl(%q) = %q, want %q", v.s, got, v.want)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+		not  []string
+	}{
+		{"script", `<p>a</p><script>alert(1)</script>`, []string{"a"}, []string{"alert"}},
+		{"escape sequences", "<p>\x1b[31mred\x1b[0m</p>", []string{"[31mred[0m"}, []string{"\x1b"}},
+		{"C1 controls", "<p>a\u009bb</p>", []string{"ab"}, []string{"\u009b"}},
+		{"image alt", "<img src=\"/i.png\" alt=\"\x07bell\">", []string{"bell"}, []string{"\x07"}},
+		{"link footnote", `<p><a href="/about">about</a></p>`, []string{"about [1]", "[1] https://example.com/about"}, nil},
+		{"javascript link", `<p><a href="javascript:alert(1)">x</a></p>`, []string{"x"}, []string{"javascript"}},
+	}
+
+	for _, v := range tests {
+		got := Text(v.body, testBase, 0)
+
+		for _, w := range v.want {
+			if !strings.Contains(got, w) {
+				t.Errorf("%s: Text(%q) = %q, want it to contain %q", v.name, v.body, got, w)
+			}
+		}
+
+		for _, w := range v.not {
+			if strings.Contains(got, w) {
+				t.Errorf("%s: Text(%q) = %q, want it not to contain %q", v.name, v.body, got, w)
+			}
+		}
+	}
+}
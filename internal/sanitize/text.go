@@ -0,0 +1,336 @@
+package sanitize
+
+import (
+	"fmt"
+	"strings"
+	"net/url"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Text renders body as plain text: paragraphs wrapped to width columns (not
+// wrapped when width is 0), lists with bullets or numbers, quotes with "> ",
+// and links numbered like [1] and listed as footnotes at the end, resolved
+// against base like in HTML. Control characters are left out.
+func Text(body, base string, width int) string {
+	r := textRenderer{width: width, footnote: make(map[string]int)}
+
+	if base_url, err := url.Parse(base); err == nil && base != "" {
+		r.base = base_url
+	}
+
+	for _, v := range parse(body) {
+		r.walk(v)
+	}
+	r.flush()
+
+	res := strings.Join(r.lines, "\n")
+
+	if len(r.links) > 0 {
+		res += "\n"
+		for i, v := range r.links {
+			res += fmt.Sprintf("\n[%d] %s", i+1, v)
+		}
+	}
+
+	return strings.TrimSpace(StripControl(res))
+}
+
+// StripControl removes the C0 and C1 control characters but newline and tab
+// from s, so text from a feed can't send escape sequences to a terminal.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+
+		if r <= 0x1F || (r >= 0x7F && r <= 0x9F) {
+			return -1
+		}
+
+		return r
+	}, s)
+}
+
+type list struct {
+	ordered bool
+	n       int
+}
+
+type textRenderer struct {
+	width int
+	lines []string
+
+	// inline collects the text of the current paragraph, lineBreak marks a
+	// <br>.
+	inline strings.Builder
+	// gap asks for a blank line before the next paragraph, quoted as deep as
+	// the outermost of the blocks asking for it.
+	gap       bool
+	gap_quote int
+
+	quote int
+	pre   int
+	lists []list
+	// first prefixes the first line of the next paragraph, rest the others,
+	// set by list items.
+	first, rest string
+
+	base     *url.URL
+	links    []string
+	footnote map[string]int
+}
+
+// lineBreak can't be in parsed text, the parser replaces NUL characters.
+const lineBreak = "\x00"
+
+var blocks = map[atom.Atom]bool{
+	atom.Address:    true,
+	atom.Article:    true,
+	atom.Aside:      true,
+	atom.Blockquote: true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dl:         true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.Figure:     true,
+	atom.Footer:     true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Header:     true,
+	atom.Main:       true,
+	atom.Ol:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Section:    true,
+	atom.Table:      true,
+	atom.Ul:         true,
+}
+
+func (r *textRenderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.inline.WriteString(StripControl(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if dropped[n.DataAtom] {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		r.inline.WriteString(lineBreak)
+		return
+	case atom.Hr:
+		r.block()
+		r.emit([]string{"----"})
+		r.block()
+		return
+	case atom.Img:
+		if alt := strings.TrimSpace(StripControl(attr(n, "alt"))); alt != "" {
+			r.inline.WriteString(" [image: " + alt + "] ")
+		} else {
+			r.inline.WriteString(" [image] ")
+		}
+		return
+	case atom.Li:
+		r.listItem(n)
+		return
+	case atom.Tr:
+		r.flush()
+		r.children(n)
+		r.flush()
+		return
+	case atom.Td, atom.Th:
+		r.children(n)
+		r.inline.WriteString("  ")
+		return
+	case atom.A:
+		r.children(n)
+		r.link(attr(n, "href"))
+		return
+	}
+
+	if !blocks[n.DataAtom] {
+		r.children(n)
+		return
+	}
+
+	// A list nested in an item goes on right below it.
+	if (n.DataAtom == atom.Ul || n.DataAtom == atom.Ol) && len(r.lists) > 0 {
+		r.flush()
+		r.lists = append(r.lists, list{ordered: n.DataAtom == atom.Ol})
+		r.children(n)
+		r.flush()
+		r.lists = r.lists[:len(r.lists)-1]
+		return
+	}
+
+	r.block()
+
+	switch n.DataAtom {
+	case atom.Blockquote:
+		r.quote++
+		r.children(n)
+		r.block()
+		r.quote--
+	case atom.Pre:
+		r.pre++
+		r.children(n)
+		r.block()
+		r.pre--
+	case atom.Ul, atom.Ol:
+		r.lists = append(r.lists, list{ordered: n.DataAtom == atom.Ol})
+		r.children(n)
+		r.flush()
+		r.lists = r.lists[:len(r.lists)-1]
+	default:
+		r.children(n)
+	}
+
+	r.block()
+}
+
+func (r *textRenderer) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+}
+
+// block ends the current paragraph, the next one starts after a blank line.
+func (r *textRenderer) block() {
+	r.flush()
+
+	if !r.gap || r.quote < r.gap_quote {
+		r.gap_quote = r.quote
+	}
+	r.gap = true
+}
+
+func (r *textRenderer) listItem(n *html.Node) {
+	r.flush()
+
+	marker := "* "
+	if len(r.lists) > 0 {
+		if l := &r.lists[len(r.lists)-1]; l.ordered {
+			l.n++
+			marker = fmt.Sprintf("%d. ", l.n)
+		}
+	}
+
+	// Nested items are indented by the items holding them.
+	outer_first, outer_rest := r.first, r.rest
+	r.first = outer_rest + marker
+	r.rest = outer_rest + strings.Repeat(" ", utf8.RuneCountInString(marker))
+
+	r.children(n)
+	r.flush()
+
+	r.first, r.rest = outer_first, outer_rest
+}
+
+// link numbers href after the link text, a link used twice keeps its number.
+func (r *textRenderer) link(href string) {
+	link, ok := cleanURL(href, r.base, true)
+	if !ok || link == "" || strings.HasPrefix(link, "#") {
+		return
+	}
+
+	n, ok := r.footnote[link]
+	if !ok {
+		r.links = append(r.links, link)
+		n = len(r.links)
+		r.footnote[link] = n
+	}
+
+	r.inline.WriteString(fmt.Sprintf(" [%d]", n))
+}
+
+// flush wraps the collected inline text into lines.
+func (r *textRenderer) flush() {
+	text := r.inline.String()
+	r.inline.Reset()
+
+	var lines []string
+
+	if r.pre > 0 {
+		text = strings.ReplaceAll(text, lineBreak, "\n")
+		lines = strings.Split(strings.Trim(text, "\n"), "\n")
+		if strings.TrimSpace(text) == "" {
+			lines = nil
+		}
+	} else {
+		for _, v := range strings.Split(text, lineBreak) {
+			words := strings.Fields(v)
+			if len(words) == 0 {
+				continue
+			}
+
+			lines = append(lines, wrap(words, r.width-utf8.RuneCountInString(r.rest)-2*r.quote)...)
+		}
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	for i := range lines {
+		if i == 0 && r.first != "" {
+			lines[i] = r.first + lines[i]
+			r.first = r.rest
+		} else {
+			lines[i] = r.rest + lines[i]
+		}
+	}
+
+	r.emit(lines)
+}
+
+func (r *textRenderer) emit(lines []string) {
+	prefix := strings.Repeat("> ", r.quote)
+
+	if r.gap && len(r.lines) > 0 {
+		r.lines = append(r.lines, strings.TrimSpace(strings.Repeat("> ", min(r.quote, r.gap_quote))))
+	}
+	r.gap = false
+
+	for _, v := range lines {
+		r.lines = append(r.lines, prefix+v)
+	}
+}
+
+// wrap fills lines of at most width runes, a longer word gets a line of its
+// own.
+func wrap(words []string, width int) []string {
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+
+	var res []string
+	line := ""
+
+	for _, v := range words {
+		if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(v) > width {
+			res = append(res, line)
+			line = ""
+		}
+
+		if line != "" {
+			line += " "
+		}
+		line += v
+	}
+
+	return append(res, line)
+}
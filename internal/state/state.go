@@ -1,6 +1,8 @@
 package state
 
 import (
+	"database/sql"
+	"gator/internal/fetch"
 	"gator/internal/config"
 	"gator/internal/database"
 )
@@ -8,6 +10,10 @@ import (
 // Top 10 dependancies. Numero 10 :
 
 type State struct {
-	DB  *database.Queries
-	Cfg *config.Config
+	DB     database.Store
+	Cfg    *config.Config
+	Conn   *sql.DB
+	Driver string
+	// Fetcher downloads feeds and pages as the config says.
+	Fetcher *fetch.Fetcher
 }
@@ -0,0 +1,422 @@
+package tui
+
+import (
+	"os"
+	"fmt"
+	"time"
+	"bufio"
+	"strings"
+	"context"
+	"os/exec"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+
+	"golang.org/x/term"
+	"github.com/mattn/go-runewidth"
+)
+
+// Full-screen terminal reader: followed feeds on the left, posts of the
+// selected feed in the middle and the selected post on the right.
+
+const (
+	paneFeeds = iota
+	panePosts
+)
+
+const postLimit = 200
+
+const help = "j/k move  h/l/tab pane  space/b scroll  o open  m read  s star  r refresh  q quit"
+
+type feedRow struct {
+	ID     int32
+	Name   string
+	Unread int64
+}
+
+type Reader struct {
+	DB   database.Store
+	User database.User
+
+	// Refresh fetches the given feed from the network, nil disables it.
+	Refresh func(ctx context.Context, feedID int32) error
+
+	feeds    []feedRow
+	posts    []database.GetFeedPostsForUserRow
+	feed_sel int
+	post_sel int
+	focus    int
+	status   string
+	out      *bufio.Writer
+
+	// body_top is the first line of the post shown, body_rows how many fit.
+	body_top  int
+	body_rows int
+}
+
+func (t *Reader) Run(ctx context.Context) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("The tui command needs an interactive terminal")
+	}
+
+	old_state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, old_state)
+
+	t.out = bufio.NewWriter(os.Stdout)
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+		t.out.Flush()
+	}()
+
+	if err := t.loadFeeds(ctx); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 16)
+	for {
+		t.draw()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+
+		quit, err := t.key(ctx, string(buf[:n]))
+		if err != nil {
+			t.status = "Error - " + err.Error()
+		}
+
+		if quit {
+			return nil
+		}
+	}
+}
+
+func (t *Reader) key(ctx context.Context, k string) (bool, error) {
+	t.status = ""
+
+	switch k {
+	case "q", "\x03":
+		return true, nil
+	case "j", "\x1b[B":
+		return false, t.move(ctx, 1)
+	case "k", "\x1b[A":
+		return false, t.move(ctx, -1)
+	case "l", "\x1b[C", "\t":
+		t.focus = panePosts
+	case "h", "\x1b[D":
+		t.focus = paneFeeds
+	case " ", "\x1b[6~":
+		t.body_top += max(1, t.body_rows-1)
+	case "b", "\x1b[5~":
+		t.body_top -= max(1, t.body_rows-1)
+	case "o":
+		if post, ok := t.current(); ok {
+			return false, openBrowser(post.Url)
+		}
+	case "m":
+		if post, ok := t.current(); ok {
+			return false, t.setRead(ctx, !post.IsRead)
+		}
+	case "s":
+		if post, ok := t.current(); ok {
+			star_params := database.SetPostStarredParams{
+				UserID:    t.User.ID,
+				PostID:    post.ID,
+				Starred:   !post.IsStarred,
+				UpdatedAt: time.Now(),
+			}
+
+			if err := t.DB.SetPostStarred(ctx, star_params); err != nil {
+				return false, err
+			}
+
+			t.posts[t.post_sel].IsStarred = !post.IsStarred
+		}
+	case "r":
+		if t.Refresh != nil && len(t.feeds) > 0 {
+			t.status = "Refreshing " + t.feeds[t.feed_sel].Name + "..."
+			t.draw()
+
+			if err := t.Refresh(ctx, t.feeds[t.feed_sel].ID); err != nil {
+				return false, err
+			}
+		}
+
+		if err := t.loadFeeds(ctx); err != nil {
+			return false, err
+		}
+
+		t.status = "Refreshed"
+	}
+
+	return false, nil
+}
+
+func (t *Reader) move(ctx context.Context, d int) error {
+	t.body_top = 0
+
+	if t.focus == paneFeeds {
+		if len(t.feeds) == 0 {
+			return nil
+		}
+
+		t.feed_sel = clamp(t.feed_sel+d, len(t.feeds))
+		t.post_sel = 0
+
+		return t.loadPosts(ctx)
+	}
+
+	t.post_sel = clamp(t.post_sel+d, len(t.posts))
+
+	return nil
+}
+
+func (t *Reader) setRead(ctx context.Context, read bool) error {
+	post := t.posts[t.post_sel]
+
+	read_params := database.SetPostReadParams{
+		UserID:    t.User.ID,
+		PostID:    post.ID,
+		Read:      read,
+		UpdatedAt: time.Now(),
+	}
+
+	if err := t.DB.SetPostRead(ctx, read_params); err != nil {
+		return err
+	}
+
+	t.posts[t.post_sel].IsRead = read
+	if read {
+		t.feeds[t.feed_sel].Unread--
+	} else {
+		t.feeds[t.feed_sel].Unread++
+	}
+
+	return nil
+}
+
+func (t *Reader) current() (database.GetFeedPostsForUserRow, bool) {
+	if len(t.posts) == 0 {
+		return database.GetFeedPostsForUserRow{}, false
+	}
+
+	return t.posts[t.post_sel], true
+}
+
+func (t *Reader) loadFeeds(ctx context.Context) error {
+	follows, err := t.DB.GetFeedFollowsForUser(ctx, t.User.ID)
+	if err != nil {
+		return err
+	}
+
+	counts, err := t.DB.GetUnreadCountsForUser(ctx, t.User.ID)
+	if err != nil {
+		return err
+	}
+
+	unread := make(map[int32]int64, len(counts))
+	for _, v := range counts {
+		unread[v.FeedID] = v.Unread
+	}
+
+	t.feeds = t.feeds[:0]
+	for _, v := range follows {
+		t.feeds = append(t.feeds, feedRow{ID: v.FeedID, Name: v.FeedName, Unread: unread[v.FeedID]})
+	}
+
+	t.feed_sel = clamp(t.feed_sel, len(t.feeds))
+
+	return t.loadPosts(ctx)
+}
+
+func (t *Reader) loadPosts(ctx context.Context) error {
+	t.posts = nil
+	if len(t.feeds) == 0 {
+		return nil
+	}
+
+	posts_params := database.GetFeedPostsForUserParams{
+		UserID: t.User.ID,
+		FeedID: t.feeds[t.feed_sel].ID,
+		Limit:  postLimit,
+	}
+
+	posts, err := t.DB.GetFeedPostsForUser(ctx, posts_params)
+	if err != nil {
+		return err
+	}
+
+	t.posts = posts
+	t.post_sel = clamp(t.post_sel, len(t.posts))
+
+	return nil
+}
+
+func (t *Reader) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width < 40 || height < 5 {
+		width, height = 80, 24
+	}
+
+	feeds_w := width / 4
+	posts_w := width * 3 / 8
+	body_w := width - feeds_w - posts_w - 2
+	rows := height - 2
+
+	feed_lines := make([]string, 0, len(t.feeds))
+	for i, v := range t.feeds {
+		line := fmt.Sprintf("%s (%d)", v.Name, v.Unread)
+		feed_lines = append(feed_lines, highlight(pad(line, feeds_w), i == t.feed_sel, t.focus == paneFeeds))
+	}
+
+	post_lines := make([]string, 0, len(t.posts))
+	for i, v := range t.posts {
+		mark := " "
+		if v.IsStarred {
+			mark = "*"
+		} else if !v.IsRead {
+			mark = "+"
+		}
+
+		line := mark + " " + v.Title
+		post_lines = append(post_lines, highlight(pad(line, posts_w), i == t.post_sel, t.focus == panePosts))
+	}
+
+	var body_lines []string
+	if len(t.posts) > 0 {
+		post := t.posts[t.post_sel]
+		body_lines = append(body_lines, wrap(post.Title, body_w)...)
+		body_lines = append(body_lines, fold(post.PublishedAt.Format("2006-01-02 15:04"), body_w)...)
+		body_lines = append(body_lines, fold(post.Url, body_w)...)
+		body_lines = append(body_lines, "")
+		for _, v := range strings.Split(sanitize.Text(post.Description, post.Url, body_w), "\n") {
+			body_lines = append(body_lines, fold(v, body_w)...)
+		}
+	}
+
+	t.body_rows = rows
+	t.body_top = clamp(t.body_top, len(body_lines)-rows+1)
+	body_lines = body_lines[min(t.body_top, len(body_lines)):]
+
+	fmt.Fprint(t.out, "\x1b[H\x1b[2J")
+
+	feed_lines = scroll(feed_lines, t.feed_sel, rows)
+	post_lines = scroll(post_lines, t.post_sel, rows)
+
+	for i := 0; i < rows; i++ {
+		fmt.Fprint(t.out, cell(feed_lines, i, feeds_w), "│", cell(post_lines, i, posts_w), "│")
+		if i < len(body_lines) {
+			fmt.Fprint(t.out, pad(body_lines[i], body_w))
+		}
+		fmt.Fprint(t.out, "\r\n")
+	}
+
+	status := t.status
+	if status == "" {
+		status = help
+	}
+	fmt.Fprint(t.out, "\x1b[7m", pad(status, width), "\x1b[0m")
+
+	t.out.Flush()
+}
+
+func cell(lines []string, i, w int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+
+	return strings.Repeat(" ", w)
+}
+
+func scroll(lines []string, sel, rows int) []string {
+	if sel < rows {
+		return lines
+	}
+
+	return lines[sel-rows+1:]
+}
+
+func highlight(s string, selected, focused bool) string {
+	if !selected {
+		return s
+	}
+
+	if focused {
+		return "\x1b[7m" + s + "\x1b[0m"
+	}
+
+	return "\x1b[1m" + s + "\x1b[0m"
+}
+
+// pad fits s into w cells of the terminal, wide characters like CJK take two.
+func pad(s string, w int) string {
+	// Feeds control most of what is drawn, their control characters could
+	// move the cursor or retitle the window.
+	s = strings.NewReplacer("\n", " ", "\t", " ").Replace(sanitize.StripControl(s))
+
+	return runewidth.FillRight(runewidth.Truncate(s, w, ""), w)
+}
+
+// fold breaks s into lines of at most w cells, for text without spaces to
+// wrap at like CJK or long URLs.
+func fold(s string, w int) []string {
+	var res []string
+	for runewidth.StringWidth(s) > w {
+		head := runewidth.Truncate(s, w, "")
+		if head == "" {
+			break
+		}
+
+		res = append(res, head)
+		s = s[len(head):]
+	}
+
+	return append(res, s)
+}
+
+func wrap(s string, w int) []string {
+	var res []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && runewidth.StringWidth(line)+1+runewidth.StringWidth(word) > w {
+				res = append(res, fold(line, w)...)
+				line = ""
+			}
+
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		res = append(res, fold(line, w)...)
+	}
+
+	return res
+}
+
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+
+	if i < 0 {
+		i = 0
+	}
+
+	return i
+}
+
+func openBrowser(url string) error {
+	browser := os.Getenv("BROWSER")
+	if browser == "" {
+		browser = "xdg-open"
+	}
+
+	return exec.Command(browser, url).Start()
+}
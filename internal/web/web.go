@@ -0,0 +1,413 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"embed"
+	"strconv"
+	"net/url"
+	"net/http"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"gator/internal/rss"
+	"gator/internal/fetch"
+	"gator/internal/fever"
+	"gator/internal/sanitize"
+	"gator/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// Server-rendered web interface for reading and managing feeds. Users log in
+// with the password set through the `apikey` command.
+
+const (
+	sessionCookie = "gator_session"
+	pageSize      = 50
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+type Server struct {
+	DB      database.Store
+	Fetcher *fetch.Fetcher
+
+	tmpl     *template.Template
+	mu       sync.Mutex
+	sessions map[string]uuid.UUID
+}
+
+func New(db database.Store, fetcher *fetch.Fetcher) (*Server, error) {
+	funcs := template.FuncMap{
+		// Post bodies are rendered as sanitized HTML, relative links
+		// pointing next to the post.
+		"body": func(s, base string) template.HTML {
+			return template.HTML(sanitize.HTML(s, base))
+		},
+		"date": func(t time.Time) string {
+			return t.Format("2006-01-02 15:04")
+		},
+	}
+
+	tmpl, err := template.New("").Funcs(funcs).ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		DB:       db,
+		Fetcher:  fetcher,
+		tmpl:     tmpl,
+		sessions: make(map[string]uuid.UUID),
+	}, nil
+}
+
+func (w *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /login", w.loginPage)
+	mux.HandleFunc("POST /login", w.login)
+	mux.HandleFunc("POST /logout", w.logout)
+
+	mux.HandleFunc("GET /{$}", w.requireUser(w.river))
+	mux.HandleFunc("GET /feeds", w.requireUser(w.feeds))
+	mux.HandleFunc("POST /feeds", w.requireUser(w.addFeed))
+	mux.HandleFunc("GET /feeds/{id}", w.requireUser(w.feed))
+	mux.HandleFunc("POST /feeds/{id}/follow", w.requireUser(w.follow))
+	mux.HandleFunc("POST /feeds/{id}/unfollow", w.requireUser(w.unfollow))
+	mux.HandleFunc("POST /posts/{id}/read", w.requireUser(w.markRead))
+
+	return mux
+}
+
+type page struct {
+	Title string
+	User  database.User
+	Error string
+	Data  any
+}
+
+// render writes the page with status, headers have to be set before it.
+func (w *Server) render(rw http.ResponseWriter, status int, name string, p page) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.WriteHeader(status)
+	if err := w.tmpl.ExecuteTemplate(rw, name, p); err != nil {
+		log.Println("Error trying to render a page -", err)
+	}
+}
+
+func (w *Server) fail(rw http.ResponseWriter, err error) {
+	log.Println("Web request failed -", err)
+	http.Error(rw, "Internal error", http.StatusInternalServerError)
+}
+
+func (w *Server) requireUser(handler func(http.ResponseWriter, *http.Request, database.User)) http.HandlerFunc {
+	return func(rw http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			http.Redirect(rw, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		w.mu.Lock()
+		id, ok := w.sessions[cookie.Value]
+		w.mu.Unlock()
+
+		if !ok {
+			http.Redirect(rw, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		user, err := w.DB.GetUserByID(r.Context(), id)
+		if err != nil {
+			http.Redirect(rw, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		handler(rw, r, user)
+	}
+}
+
+func (w *Server) loginPage(rw http.ResponseWriter, r *http.Request) {
+	w.render(rw, http.StatusOK, "login.html", page{Title: "Log in"})
+}
+
+func (w *Server) login(rw http.ResponseWriter, r *http.Request) {
+	name, password := r.FormValue("name"), r.FormValue("password")
+
+	user, err := w.DB.GetUser(r.Context(), name)
+	if err != nil || !user.ApiKey.Valid || subtle.ConstantTimeCompare([]byte(user.ApiKey.String), []byte(fever.APIKey(name, password))) != 1 {
+		w.render(rw, http.StatusUnauthorized, "login.html", page{Title: "Log in", Error: "Wrong user name or password"})
+		return
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		w.fail(rw, err)
+		return
+	}
+	token := hex.EncodeToString(buf)
+
+	w.mu.Lock()
+	w.sessions[token] = user.ID
+	w.mu.Unlock()
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.Redirect(rw, r, "/", http.StatusSeeOther)
+}
+
+func (w *Server) logout(rw http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		w.mu.Lock()
+		delete(w.sessions, cookie.Value)
+		w.mu.Unlock()
+	}
+
+	http.SetCookie(rw, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+	http.Redirect(rw, r, "/login", http.StatusSeeOther)
+}
+
+func (w *Server) river(rw http.ResponseWriter, r *http.Request, user database.User) {
+	var posts any
+	var err error
+
+	// Read posts are left out by the query, the page still fills up.
+	if r.FormValue("unread") != "" {
+		posts, err = w.DB.GetUnreadRiverForUser(r.Context(), database.GetUnreadRiverForUserParams{UserID: user.ID, Limit: pageSize})
+	} else {
+		posts, err = w.DB.GetRiverForUser(r.Context(), database.GetRiverForUserParams{UserID: user.ID, Limit: pageSize})
+	}
+
+	if err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	w.render(rw, http.StatusOK, "river.html", page{Title: "River of news", User: user, Data: posts})
+}
+
+type feedEntry struct {
+	Feed      database.Feed
+	Following bool
+}
+
+func (w *Server) feeds(rw http.ResponseWriter, r *http.Request, user database.User) {
+	w.renderFeeds(rw, r, user, http.StatusOK, "")
+}
+
+func (w *Server) renderFeeds(rw http.ResponseWriter, r *http.Request, user database.User, status int, form_err string) {
+	feeds, err := w.DB.GetFeeds(r.Context())
+	if err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	followed, err := w.DB.GetFollowedFeedsForUser(r.Context(), user.ID)
+	if err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	is_followed := make(map[int32]bool, len(followed))
+	for _, v := range followed {
+		is_followed[v.ID] = true
+	}
+
+	entries := make([]feedEntry, 0, len(feeds))
+	for _, v := range feeds {
+		entries = append(entries, feedEntry{Feed: v, Following: is_followed[v.ID]})
+	}
+
+	w.render(rw, status, "feeds.html", page{Title: "Feeds", User: user, Error: form_err, Data: entries})
+}
+
+func (w *Server) addFeed(rw http.ResponseWriter, r *http.Request, user database.User) {
+	name, page_url := r.FormValue("name"), r.FormValue("url")
+	if name == "" || page_url == "" {
+		w.renderFeeds(rw, r, user, http.StatusBadRequest, "Expected name and URL of the feed")
+		return
+	}
+
+	feed_url, err := rss.DiscoverFeed(r.Context(), w.Fetcher, page_url)
+	if err != nil {
+		w.renderFeeds(rw, r, user, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c_time := time.Now()
+
+	feed := database.CreateFeedParams{
+		CreatedAt: c_time,
+		UpdatedAt: c_time,
+		Name:      name,
+		Url:       feed_url,
+		UserID:    user.ID,
+	}
+
+	new_feed, err := w.DB.CreateFeed(r.Context(), feed)
+	if err != nil {
+		w.renderFeeds(rw, r, user, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	follow_struct := database.CreateFeedFollowParams{
+		CreatedAt: c_time,
+		UpdatedAt: c_time,
+		UserID:    user.ID,
+		FeedID:    new_feed.ID,
+	}
+
+	if _, err := w.DB.CreateFeedFollow(r.Context(), follow_struct); err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	http.Redirect(rw, r, fmt.Sprintf("/feeds/%d", new_feed.ID), http.StatusSeeOther)
+}
+
+type feedPage struct {
+	Feed      database.Feed
+	Following bool
+	Posts     []database.GetFeedPostsForUserRow
+}
+
+func (w *Server) feed(rw http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := w.feedFromPath(rw, r)
+	if !ok {
+		return
+	}
+
+	posts, err := w.DB.GetFeedPostsForUser(r.Context(), database.GetFeedPostsForUserParams{UserID: user.ID, FeedID: feed.ID, Limit: pageSize})
+	if err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	followed, err := w.DB.GetFollowedFeedsForUser(r.Context(), user.ID)
+	if err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	data := feedPage{Feed: feed, Posts: posts}
+	for _, v := range followed {
+		if v.ID == feed.ID {
+			data.Following = true
+		}
+	}
+
+	w.render(rw, http.StatusOK, "feed.html", page{Title: feed.Name, User: user, Data: data})
+}
+
+func (w *Server) follow(rw http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := w.feedFromPath(rw, r)
+	if !ok {
+		return
+	}
+
+	c_time := time.Now()
+
+	follow_struct := database.CreateFeedFollowParams{
+		CreatedAt: c_time,
+		UpdatedAt: c_time,
+		UserID:    user.ID,
+		FeedID:    feed.ID,
+	}
+
+	if _, err := w.DB.CreateFeedFollow(r.Context(), follow_struct); err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	redirectBack(rw, r)
+}
+
+func (w *Server) unfollow(rw http.ResponseWriter, r *http.Request, user database.User) {
+	feed, ok := w.feedFromPath(rw, r)
+	if !ok {
+		return
+	}
+
+	unf_struct := database.UnfollowFeedParams{
+		UserID: user.ID,
+		FeedID: feed.ID,
+	}
+
+	if err := w.DB.UnfollowFeed(r.Context(), unf_struct); err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	redirectBack(rw, r)
+}
+
+func (w *Server) markRead(rw http.ResponseWriter, r *http.Request, user database.User) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.NotFound(rw, r)
+		return
+	}
+
+	// Only posts of followed feeds, the same as the user can see.
+	posts, err := w.DB.GetPostItemsByIDs(r.Context(), database.GetPostItemsByIDsParams{UserID: user.ID, Ids: []int32{int32(id)}})
+	if err != nil {
+		w.fail(rw, err)
+		return
+	} else if len(posts) == 0 {
+		http.NotFound(rw, r)
+		return
+	}
+
+	read_params := database.SetPostReadParams{
+		UserID:    user.ID,
+		PostID:    int32(id),
+		Read:      r.FormValue("read") != "0",
+		UpdatedAt: time.Now(),
+	}
+
+	if err := w.DB.SetPostRead(r.Context(), read_params); err != nil {
+		w.fail(rw, err)
+		return
+	}
+
+	redirectBack(rw, r)
+}
+
+func (w *Server) feedFromPath(rw http.ResponseWriter, r *http.Request) (database.Feed, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil {
+		http.NotFound(rw, r)
+		return database.Feed{}, false
+	}
+
+	feed, err := w.DB.GetFeedByID(r.Context(), int32(id))
+	if err != nil {
+		http.NotFound(rw, r)
+		return database.Feed{}, false
+	}
+
+	return feed, true
+}
+
+// redirectBack sends the browser back to the local page the form was posted
+// from, so buttons return the user to where they were.
+func redirectBack(rw http.ResponseWriter, r *http.Request) {
+	back := "/"
+	if ref, err := url.Parse(r.Referer()); err == nil && ref.Host == r.Host && ref.Path != "" {
+		back = ref.RequestURI()
+	}
+
+	http.Redirect(rw, r, back, http.StatusSeeOther)
+}
@@ -1,35 +1,132 @@
 package main
 
 import (
+	"os"
+	"fmt"
 	"log"
+	"context"
+	"strings"
 	"database/sql"
+	"gator/internal/fetch"
 	"gator/internal/state"
 	"gator/internal/config"
+	"gator/internal/migrate"
 	"gator/internal/handlers"
 	"gator/internal/database"
+	"gator/internal/database/sqlite"
 
 	_ "github.com/lib/pq"
 )
 
+// globalFlags takes the flags valid before any command, --config and
+// --profile, out of the arguments.
+func globalFlags(os_args []string) (string, string, []string) {
+	var cfg_path, profile string
+
+	flags := map[string]*string{
+		"--config":  &cfg_path,
+		"--profile": &profile,
+	}
+
+	res := []string{os_args[0]}
+
+	i := 1
+	for ; i < len(os_args); i++ {
+		name, value, has_value := strings.Cut(os_args[i], "=")
+
+		target, ok := flags[name]
+		if !ok {
+			break
+		}
+
+		if !has_value {
+			if i+1 >= len(os_args) {
+				log.Fatal(fmt.Errorf("Expected a value after %s", name))
+			}
+
+			value = os_args[i+1]
+			i++
+		}
+
+		*target = value
+	}
+
+	// Everything from the command name on belongs to the command.
+	return cfg_path, profile, append(res, os_args[i:]...)
+}
+
 func main() {
-	new_cfg, err := config.Read()
+	cfg_path, profile, os_args := globalFlags(os.Args)
+
+	new_cmds := handlers.Commands{}
+	new_cmds.Register_all_cmds()
+
+	fnc, cmnd := handlers.Handle_Input(&new_cmds, os_args)
+
+	// init writes the config the other commands need, so it runs without one.
+	if cmnd.Name() == "init" {
+		path, err := config.FilePath(cfg_path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		new_cfg := config.New(path)
+		if err := fnc(&state.State{Cfg: &new_cfg}, cmnd); err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	new_cfg, err := config.Load(cfg_path, profile)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	db, err := sql.Open("postgres", new_cfg.DB_URL)
+	fetcher, err := fetch.New(new_cfg.FetchOptions())
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	dbQueries := database.New(db)
+	new_state := state.State{Cfg: &new_cfg, Fetcher: fetcher}
 
-	new_state := state.State{dbQueries, &new_cfg}
+	// profile only edits the config, the database may not even exist yet.
+	if cmnd.Name() == "profile" {
+		if err := fnc(&new_state, cmnd); err != nil {
+			log.Fatal(err)
+		}
 
-	new_cmds := handlers.Commands{}
-	new_cmds.Register_all_cmds()
-	
-	fnc, cmnd := handlers.Handle_Input(&new_cmds)
+		return
+	}
+
+	if path, ok := strings.CutPrefix(new_cfg.DB_URL, "sqlite://"); ok {
+		dbQueries, db, err := sqlite.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		new_state.DB, new_state.Conn, new_state.Driver = dbQueries, db, "sqlite"
+	} else {
+		db, err := sql.Open("postgres", new_cfg.DB_URL)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		new_state.DB, new_state.Conn, new_state.Driver = database.New(db), db, "postgres"
+	}
+
+	if cmnd.Name() != "migrate" {
+		dialect := migrate.ForDriver(new_state.Driver)
+
+		migs, err := dialect.Load()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := dialect.Check(context.Background(), new_state.Conn, migs); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	if err := fnc(&new_state, cmnd); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,51 @@
+-- name: CreateCategory :one
+INSERT INTO categories(created_at, updated_at, user_id, name)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4
+)
+RETURNING *;
+-- name: DeleteCategory :execrows
+DELETE FROM categories
+WHERE user_id = $1 AND name = $2;
+-- name: GetCategory :one
+SELECT *
+FROM categories
+WHERE user_id = $1 AND name = $2;
+-- name: GetCategoriesForUser :many
+SELECT categories.*, COUNT(feed_follow_categories.feed_follow_id) AS feeds
+FROM categories
+LEFT JOIN feed_follow_categories
+ON feed_follow_categories.category_id = categories.id
+WHERE categories.user_id = $1
+GROUP BY categories.id
+ORDER BY categories.name;
+-- name: GetFollowCategoriesForUser :many
+SELECT feed_follows.feed_id, categories.name
+FROM feed_follow_categories
+INNER JOIN feed_follows
+ON feed_follow_categories.feed_follow_id = feed_follows.id
+INNER JOIN categories
+ON feed_follow_categories.category_id = categories.id
+WHERE feed_follows.user_id = $1
+ORDER BY categories.name;
+-- name: CategorizeFollow :exec
+INSERT INTO feed_follow_categories(feed_follow_id, category_id)
+VALUES(
+	$1,
+	$2
+)
+ON CONFLICT DO NOTHING;
+-- name: UncategorizeFollow :execrows
+DELETE FROM feed_follow_categories
+WHERE feed_follow_id = $1 AND category_id = $2;
+-- name: GetAllCategories :many
+SELECT *
+FROM categories
+ORDER BY id;
+-- name: GetAllFeedFollowCategories :many
+SELECT *
+FROM feed_follow_categories
+ORDER BY feed_follow_id, category_id;
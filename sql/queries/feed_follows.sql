@@ -27,3 +27,15 @@ WHERE feed_follows.user_id = $1;
 -- name: UnfollowFeed :exec
 DELETE FROM feed_follows
 WHERE user_id = $1 AND feed_id = $2;
+-- name: GetAllFeedFollows :many
+SELECT *
+FROM feed_follows
+ORDER BY id;
+-- name: CountFeedFollowers :one
+SELECT COUNT(*)
+FROM feed_follows
+WHERE feed_id = $1;
+-- name: GetFeedFollow :one
+SELECT *
+FROM feed_follows
+WHERE user_id = $1 AND feed_id = $2;
@@ -15,9 +15,82 @@ SELECT * FROM feeds
 WHERE url = $1;
 -- name: MarkFeedFetched :exec
 UPDATE feeds
-SET updated_at = $2, last_fetched_at = $2
+SET updated_at = $2, last_fetched_at = $2, next_fetch_at = $3
 WHERE feeds.id = $1;
 -- name: GetNextFeedToFetch :one
 SELECT * FROM feeds
-ORDER BY last_fetched_at ASC NULLS FIRST
+ORDER BY next_fetch_at ASC NULLS FIRST, last_fetched_at ASC NULLS FIRST
 LIMIT 1;
+-- name: SetFeedNextFetch :exec
+UPDATE feeds
+SET next_fetch_at = $2
+WHERE id = $1;
+-- name: GetFollowedFeedsForUser :many
+SELECT feeds.*
+FROM feeds
+INNER JOIN feed_follows
+ON feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feeds.id;
+-- name: GetFeedByID :one
+SELECT * FROM feeds
+WHERE id = $1;
+-- name: GetDueFeeds :many
+SELECT * FROM feeds
+WHERE next_fetch_at IS NULL OR next_fetch_at <= $1
+ORDER BY next_fetch_at ASC NULLS FIRST;
+-- name: SetFeedRetention :exec
+UPDATE feeds
+SET updated_at = $2, retention_days = $3, retention_max_posts = $4
+WHERE id = $1;
+-- name: DeleteFeed :execrows
+DELETE FROM feeds
+WHERE id = $1;
+-- name: RestoreFeed :one
+INSERT INTO feeds(created_at, updated_at, name, url, user_id, last_fetched_at, next_fetch_at, retention_days, retention_max_posts, title, site_link, description, language, image_url, generator, full_text)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10,
+	$11,
+	$12,
+	$13,
+	$14,
+	$15,
+	$16
+)
+RETURNING id;
+-- name: RenameFeed :exec
+UPDATE feeds
+SET updated_at = $2, name = $3
+WHERE id = $1;
+-- name: SetFeedURL :exec
+UPDATE feeds
+SET updated_at = $2, url = $3, last_fetched_at = NULL, next_fetch_at = NULL
+WHERE id = $1;
+-- name: SetFeedMetadata :exec
+UPDATE feeds
+SET title = $2, site_link = $3, description = $4, language = $5, image_url = $6, generator = $7, parse_warnings = $8
+WHERE id = $1;
+-- name: GetFollowedFeedsInCategory :many
+SELECT feeds.*
+FROM feeds
+INNER JOIN feed_follows
+ON feeds.id = feed_follows.feed_id
+INNER JOIN feed_follow_categories
+ON feed_follow_categories.feed_follow_id = feed_follows.id
+INNER JOIN categories
+ON feed_follow_categories.category_id = categories.id
+WHERE feed_follows.user_id = $1 AND categories.name = $2
+ORDER BY feeds.id;
+-- name: SetFeedFullText :exec
+UPDATE feeds
+SET updated_at = $2, full_text = $3
+WHERE id = $1;
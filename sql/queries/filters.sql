@@ -0,0 +1,38 @@
+-- name: CreateFilter :one
+INSERT INTO filters(created_at, updated_at, user_id, feed_id, field, pattern, action)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING *;
+-- name: GetFilter :one
+SELECT *
+FROM filters
+WHERE id = $1 AND user_id = $2;
+-- name: GetFiltersForUser :many
+SELECT *
+FROM filters
+WHERE user_id = $1
+ORDER BY id;
+-- name: GetFiltersForFeed :many
+SELECT filters.*
+FROM filters
+WHERE (filters.feed_id = $1 OR filters.feed_id IS NULL)
+AND EXISTS (
+	SELECT 1
+	FROM feed_follows
+	WHERE feed_follows.user_id = filters.user_id AND feed_follows.feed_id = $1
+)
+ORDER BY filters.id;
+-- name: DeleteFilter :execrows
+DELETE FROM filters
+WHERE id = $1 AND user_id = $2;
+-- name: GetAllFilters :many
+SELECT *
+FROM filters
+ORDER BY id;
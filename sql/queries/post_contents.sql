@@ -0,0 +1,17 @@
+-- name: SetPostContent :exec
+INSERT INTO post_contents(post_id, content, fetched_at)
+VALUES(
+	$1,
+	$2,
+	$3
+)
+ON CONFLICT(post_id) DO UPDATE
+SET content = EXCLUDED.content, fetched_at = EXCLUDED.fetched_at;
+-- name: GetPostContent :one
+SELECT *
+FROM post_contents
+WHERE post_id = $1;
+-- name: GetAllPostContents :many
+SELECT *
+FROM post_contents
+ORDER BY post_id;
@@ -0,0 +1,119 @@
+-- name: SetPostRead :exec
+INSERT INTO post_states(user_id, post_id, read, updated_at)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4
+)
+ON CONFLICT(user_id, post_id) DO UPDATE
+SET read = EXCLUDED.read, updated_at = EXCLUDED.updated_at;
+-- name: SetPostStarred :exec
+INSERT INTO post_states(user_id, post_id, starred, updated_at)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4
+)
+ON CONFLICT(user_id, post_id) DO UPDATE
+SET starred = EXCLUDED.starred, updated_at = EXCLUDED.updated_at;
+-- name: MarkFeedReadBefore :exec
+INSERT INTO post_states(user_id, post_id, read, updated_at)
+SELECT $1, posts.id, TRUE, $2
+FROM posts
+WHERE posts.feed_id = $3 AND posts.created_at <= $4
+ON CONFLICT(user_id, post_id) DO UPDATE
+SET read = TRUE, updated_at = EXCLUDED.updated_at;
+-- name: MarkAllReadBefore :exec
+INSERT INTO post_states(user_id, post_id, read, updated_at)
+SELECT feed_follows.user_id, posts.id, TRUE, $2
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1 AND posts.created_at <= $3
+ON CONFLICT(user_id, post_id) DO UPDATE
+SET read = TRUE, updated_at = EXCLUDED.updated_at;
+-- name: GetUnreadPostIDsForUser :many
+SELECT posts.id
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.read, FALSE) = FALSE
+ORDER BY posts.id;
+-- name: GetStarredPostIDsForUser :many
+SELECT post_id
+FROM post_states
+WHERE user_id = $1 AND starred = TRUE
+ORDER BY post_id;
+-- name: CountPostsForUser :one
+SELECT COUNT(*)
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1;
+-- name: GetPostItemsSince :many
+SELECT posts.*, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.id > $2 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.id ASC
+LIMIT $3;
+-- name: GetPostItemsBefore :many
+SELECT posts.*, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.id < $2 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.id DESC
+LIMIT $3;
+-- name: GetPostItemsByIDs :many
+SELECT posts.*, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND posts.id = ANY(sqlc.arg(ids)::int[])
+ORDER BY posts.id ASC;
+-- name: GetUnreadCountsForUser :many
+SELECT feed_follows.feed_id, COUNT(posts.id) AS unread
+FROM feed_follows
+INNER JOIN posts
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.read, FALSE) = FALSE
+GROUP BY feed_follows.feed_id;
+-- name: GetAllPostStates :many
+SELECT *
+FROM post_states
+ORDER BY user_id, post_id;
+-- name: RestorePostState :exec
+INSERT INTO post_states(user_id, post_id, read, starred, updated_at, hidden)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6
+);
+-- name: HidePost :exec
+INSERT INTO post_states(user_id, post_id, read, hidden, updated_at)
+VALUES(
+	$1,
+	$2,
+	TRUE,
+	TRUE,
+	$3
+)
+ON CONFLICT(user_id, post_id) DO UPDATE
+SET read = TRUE, hidden = TRUE, updated_at = EXCLUDED.updated_at;
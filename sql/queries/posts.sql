@@ -1,4 +1,4 @@
--- name: CreatePost :exec
+-- name: CreatePost :execrows
 INSERT INTO posts(created_at, updated_at, title, url, description, published_at, feed_id)
 VALUES(
 	$1,
@@ -8,7 +8,8 @@ VALUES(
 	$5,
 	$6,
 	$7
-);
+)
+ON CONFLICT(url) DO NOTHING;
 -- name: GetPostsByUser :many
 SELECT posts.*
 FROM posts
@@ -16,5 +17,117 @@ INNER JOIN feed_follows
 ON posts.feed_id = feed_follows.feed_id
 INNER JOIN users
 ON feed_follows.user_id = users.id
-WHERE users.name = $1
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = users.id
+WHERE users.name = $1 AND COALESCE(post_states.hidden, FALSE) = FALSE
+LIMIT $2;
+-- name: GetRiverForUser :many
+SELECT posts.*, feeds.name AS feed_name, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.published_at DESC
 LIMIT $2;
+-- name: GetUnreadRiverForUser :many
+SELECT posts.*, feeds.name AS feed_name, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+INNER JOIN feeds
+ON posts.feed_id = feeds.id
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND COALESCE(post_states.hidden, FALSE) = FALSE AND COALESCE(post_states.read, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $2;
+-- name: GetFeedPostsForUser :many
+SELECT posts.*, COALESCE(post_states.read, FALSE) AS is_read, COALESCE(post_states.starred, FALSE) AS is_starred
+FROM posts
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = $1
+WHERE posts.feed_id = $2 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $3;
+-- name: DeletePostsPublishedBefore :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1 AND posts.published_at < $2
+AND NOT EXISTS (
+	SELECT 1 FROM post_states
+	WHERE post_states.post_id = posts.id AND post_states.starred = TRUE
+)
+AND NOT EXISTS (
+	SELECT 1 FROM post_tags
+	WHERE post_tags.post_id = posts.id
+);
+-- name: DeletePostsBeyondLimit :execrows
+DELETE FROM posts
+WHERE posts.feed_id = $1
+AND posts.id NOT IN (
+	SELECT kept.id FROM posts AS kept
+	WHERE kept.feed_id = $1
+	ORDER BY kept.published_at DESC, kept.id DESC
+	LIMIT $2
+)
+AND NOT EXISTS (
+	SELECT 1 FROM post_states
+	WHERE post_states.post_id = posts.id AND post_states.starred = TRUE
+)
+AND NOT EXISTS (
+	SELECT 1 FROM post_tags
+	WHERE post_tags.post_id = posts.id
+);
+-- name: DeletePosts :execrows
+DELETE FROM posts;
+-- name: GetAllPosts :many
+SELECT *
+FROM posts
+ORDER BY id;
+-- name: RestorePost :one
+INSERT INTO posts(created_at, updated_at, title, url, description, published_at, feed_id)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7
+)
+RETURNING id;
+-- name: CountPostsForFeed :one
+SELECT COUNT(*)
+FROM posts
+WHERE feed_id = $1;
+-- name: GetPostsByUserInCategory :many
+SELECT posts.*
+FROM posts
+INNER JOIN feed_follows
+ON posts.feed_id = feed_follows.feed_id
+INNER JOIN feed_follow_categories
+ON feed_follow_categories.feed_follow_id = feed_follows.id
+INNER JOIN categories
+ON feed_follow_categories.category_id = categories.id
+LEFT JOIN post_states
+ON post_states.post_id = posts.id AND post_states.user_id = feed_follows.user_id
+WHERE feed_follows.user_id = $1 AND categories.name = $2 AND COALESCE(post_states.hidden, FALSE) = FALSE
+ORDER BY posts.published_at DESC
+LIMIT $3;
+-- name: GetPostByURL :one
+SELECT *
+FROM posts
+WHERE url = $1;
+-- name: GetPostsByUserWithTag :many
+SELECT posts.*
+FROM posts
+INNER JOIN post_tags
+ON post_tags.post_id = posts.id
+INNER JOIN tags
+ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1 AND tags.name = $2
+ORDER BY posts.published_at DESC
+LIMIT $3;
@@ -0,0 +1,53 @@
+-- name: CreateTag :one
+INSERT INTO tags(created_at, updated_at, user_id, name)
+VALUES(
+	$1,
+	$2,
+	$3,
+	$4
+)
+RETURNING *;
+-- name: GetTag :one
+SELECT *
+FROM tags
+WHERE user_id = $1 AND name = $2;
+-- name: DeleteTagIfUnused :exec
+DELETE FROM tags
+WHERE id = $1 AND NOT EXISTS (
+	SELECT 1
+	FROM post_tags
+	WHERE post_tags.tag_id = tags.id
+);
+-- name: GetTagsForUser :many
+SELECT tags.*, COUNT(post_tags.post_id) AS posts
+FROM tags
+LEFT JOIN post_tags
+ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1
+GROUP BY tags.id
+ORDER BY tags.name;
+-- name: GetTagsForPost :many
+SELECT tags.name
+FROM tags
+INNER JOIN post_tags
+ON post_tags.tag_id = tags.id
+WHERE tags.user_id = $1 AND post_tags.post_id = $2
+ORDER BY tags.name;
+-- name: TagPost :exec
+INSERT INTO post_tags(post_id, tag_id)
+VALUES(
+	$1,
+	$2
+)
+ON CONFLICT DO NOTHING;
+-- name: UntagPost :execrows
+DELETE FROM post_tags
+WHERE post_id = $1 AND tag_id = $2;
+-- name: GetAllTags :many
+SELECT *
+FROM tags
+ORDER BY id;
+-- name: GetAllPostTags :many
+SELECT *
+FROM post_tags
+ORDER BY post_id, tag_id;
@@ -1,10 +1,11 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, name)
+INSERT INTO users (id, created_at, updated_at, name, role)
 VALUES (
 	$1,
 	$2,
 	$3,
-	$4
+	$4,
+	$5
 )
 RETURNING *;
 -- name: GetUser :one
@@ -20,3 +21,40 @@ DELETE FROM users;
 -- name: GetUsers :many
 SELECT name
 FROM users;
+-- name: GetUserByAPIKey :one
+SELECT *
+FROM users
+WHERE api_key = $1;
+-- name: SetUserAPIKey :exec
+UPDATE users
+SET updated_at = $2, api_key = $3
+WHERE id = $1;
+-- name: DeleteUser :execrows
+DELETE FROM users
+WHERE name = $1;
+-- name: GetAllUsers :many
+SELECT *
+FROM users
+ORDER BY created_at;
+-- name: RestoreUser :exec
+INSERT INTO users (id, created_at, updated_at, name, api_key, role)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6
+);
+-- name: RenameUser :exec
+UPDATE users
+SET updated_at = $2, name = $3, api_key = NULL
+WHERE id = $1;
+-- name: SetUserRole :exec
+UPDATE users
+SET updated_at = $2, role = $3
+WHERE id = $1;
+-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE role = 'admin';
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE users
+ADD api_key TEXT UNIQUE;
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN api_key;
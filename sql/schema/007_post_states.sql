@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE post_states(
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	read BOOLEAN NOT NULL DEFAULT FALSE,
+	starred BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_at TIMESTAMP NOT NULL,
+	PRIMARY KEY(user_id, post_id)
+);
+-- +goose Down
+DROP TABLE post_states;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD retention_days INTEGER,
+ADD retention_max_posts INTEGER;
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN retention_days,
+DROP COLUMN retention_max_posts;
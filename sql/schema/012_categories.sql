@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE categories(
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	UNIQUE(user_id, name)
+);
+CREATE TABLE feed_follow_categories(
+	feed_follow_id INTEGER NOT NULL REFERENCES feed_follows(id) ON DELETE CASCADE,
+	category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+	PRIMARY KEY(feed_follow_id, category_id)
+);
+-- +goose Down
+DROP TABLE feed_follow_categories;
+DROP TABLE categories;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE tags(
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	UNIQUE(user_id, name)
+);
+CREATE TABLE post_tags(
+	post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+	PRIMARY KEY(post_id, tag_id)
+);
+-- +goose Down
+DROP TABLE post_tags;
+DROP TABLE tags;
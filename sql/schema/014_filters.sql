@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE filters(
+	id SERIAL PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	feed_id INTEGER REFERENCES feeds(id) ON DELETE CASCADE,
+	field TEXT NOT NULL CHECK (field IN ('title', 'content', 'any')),
+	pattern TEXT NOT NULL,
+	action TEXT NOT NULL
+);
+ALTER TABLE post_states
+ADD hidden BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose Down
+ALTER TABLE post_states
+DROP COLUMN hidden;
+DROP TABLE filters;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD full_text BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE TABLE post_contents(
+	post_id INTEGER PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	fetched_at TIMESTAMP NOT NULL
+);
+-- +goose Down
+DROP TABLE post_contents;
+ALTER TABLE feeds
+DROP COLUMN full_text;
@@ -0,0 +1,8 @@
+package schema
+
+import "embed"
+
+// FS holds the goose migrations so the binary can apply them itself.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE feeds(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	name TEXT NOT NULL,
+	url TEXT NOT NULL UNIQUE,
+	user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose Down
+DROP TABLE feeds;
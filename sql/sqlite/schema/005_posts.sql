@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE posts(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	title TEXT NOT NULL,
+	url TEXT UNIQUE NOT NULL,
+	description TEXT NOT NULL,
+	published_at TIMESTAMP NOT NULL,
+	feed_id INTEGER NOT NULL REFERENCES feeds(id) ON DELETE CASCADE
+);
+-- +goose Down
+DROP TABLE posts;
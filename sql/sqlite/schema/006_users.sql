@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE users
+ADD api_key TEXT;
+CREATE UNIQUE INDEX users_api_key ON users(api_key);
+-- +goose Down
+DROP INDEX users_api_key;
+ALTER TABLE users
+DROP COLUMN api_key;
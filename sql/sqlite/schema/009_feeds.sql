@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD retention_days INTEGER;
+ALTER TABLE feeds
+ADD retention_max_posts INTEGER;
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN retention_max_posts;
+ALTER TABLE feeds
+DROP COLUMN retention_days;
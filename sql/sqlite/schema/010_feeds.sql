@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD title TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds
+ADD site_link TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds
+ADD description TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds
+ADD language TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds
+ADD image_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds
+ADD generator TEXT NOT NULL DEFAULT '';
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN generator;
+ALTER TABLE feeds
+DROP COLUMN image_url;
+ALTER TABLE feeds
+DROP COLUMN language;
+ALTER TABLE feeds
+DROP COLUMN description;
+ALTER TABLE feeds
+DROP COLUMN site_link;
+ALTER TABLE feeds
+DROP COLUMN title;
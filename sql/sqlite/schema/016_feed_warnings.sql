@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD parse_warnings TEXT NOT NULL DEFAULT '';
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN parse_warnings;
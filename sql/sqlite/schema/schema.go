@@ -0,0 +1,9 @@
+package schema
+
+import "embed"
+
+// FS holds the SQLite flavour of the goose migrations in sql/schema, kept at
+// the same version numbers.
+//
+//go:embed *.sql
+var FS embed.FS